@@ -59,6 +59,7 @@ func main() {
 	// =========================
 	app.InitDB()
 	app.RunMigrations()
+	app.InitStorage()
 
 	// =========================
 	// Seed (REALISTIC)
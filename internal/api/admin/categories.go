@@ -0,0 +1,212 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// KATEGORI MENU (ADMIN STAN)
+// =========================
+//
+
+type kategoriPayload struct {
+	Nama   *string `json:"nama,omitempty"`
+	Urutan *int    `json:"urutan,omitempty"`
+}
+
+func kategoriJSON(k app.MenuKategori) gin.H {
+	return gin.H{
+		"kategori_id": k.PublicID,
+		"nama":        k.Nama,
+		"urutan":      k.Urutan,
+	}
+}
+
+// findStanKategori kategori milik stan yang login (stan lain → 404)
+func findStanKategori(c *gin.Context, stan *app.Stan) (*app.MenuKategori, bool) {
+	var k app.MenuKategori
+	if err := app.DB.
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		First(&k).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "kategori not found"})
+		return nil, false
+	}
+	return &k, true
+}
+
+// kategoriNameTaken true jika nama sudah dipakai kategori lain di stan yang sama
+func kategoriNameTaken(stanID uint, nama string, exceptID uint) bool {
+	var count int64
+	app.DB.Model(&app.MenuKategori{}).
+		Where("stan_id = ? AND nama = ? AND id <> ?", stanID, nama, exceptID).
+		Count(&count)
+	return count > 0
+}
+
+// resolveKategori public id kategori → id internal ("" → nil = tanpa kategori)
+func resolveKategori(stanID uint, pub string) (*uint, error) {
+	pub = strings.TrimSpace(pub)
+	if pub == "" {
+		return nil, nil
+	}
+	var k app.MenuKategori
+	if err := app.DB.Where("public_id = ? AND stan_id = ?", pub, stanID).First(&k).Error; err != nil {
+		return nil, errKategoriNotFound
+	}
+	return &k.ID, nil
+}
+
+// POST /api/admin/categories
+func AdminCreateKategori(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var p kategoriPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	nama := strings.TrimSpace(derefStr(p.Nama))
+	if nama == "" || len([]rune(nama)) > 50 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "nama is required (max 50 characters)"})
+		return
+	}
+	if kategoriNameTaken(stan.ID, nama, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "kategori already exists"})
+		return
+	}
+
+	k := app.MenuKategori{StanID: stan.ID, Nama: nama}
+	if p.Urutan != nil {
+		k.Urutan = *p.Urutan
+	}
+	if err := app.DB.Create(&k).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create kategori"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, kategoriJSON(k))
+}
+
+// GET /api/admin/categories
+func AdminListKategori(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var list []app.MenuKategori
+	if err := app.DB.
+		Where("stan_id = ?", stan.ID).
+		Order("urutan ASC, nama ASC").
+		Find(&list).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list kategori"})
+		return
+	}
+
+	// jumlah menu per kategori
+	var counts []struct {
+		KategoriID uint
+		Total      int64
+	}
+	app.DB.Model(&app.Menu{}).
+		Select("kategori_id, COUNT(*) AS total").
+		Where("stan_id = ? AND kategori_id IS NOT NULL", stan.ID).
+		Group("kategori_id").
+		Scan(&counts)
+	countByID := map[uint]int64{}
+	for _, r := range counts {
+		countByID[r.KategoriID] = r.Total
+	}
+
+	out := make([]gin.H, 0, len(list))
+	for _, k := range list {
+		item := kategoriJSON(k)
+		item["menu_count"] = countByID[k.ID]
+		out = append(out, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"categories": out})
+}
+
+// PUT /api/admin/categories/:id
+func AdminUpdateKategori(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+	k, ok := findStanKategori(c, stan)
+	if !ok {
+		return
+	}
+
+	var p kategoriPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if p.Nama != nil {
+		nama := strings.TrimSpace(*p.Nama)
+		if nama == "" || len([]rune(nama)) > 50 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "nama is required (max 50 characters)"})
+			return
+		}
+		if kategoriNameTaken(stan.ID, nama, k.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "kategori already exists"})
+			return
+		}
+		k.Nama = nama
+	}
+	if p.Urutan != nil {
+		k.Urutan = *p.Urutan
+	}
+
+	if err := app.DB.Save(k).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update kategori"})
+		return
+	}
+
+	c.JSON(http.StatusOK, kategoriJSON(*k))
+}
+
+// DELETE /api/admin/categories/:id
+// Menu di kategori ini tidak ikut terhapus (kategori → kosong).
+func AdminDeleteKategori(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+	k, ok := findStanKategori(c, stan)
+	if !ok {
+		return
+	}
+
+	tx := app.DB.Begin()
+	if err := tx.Model(&app.Menu{}).
+		Where("kategori_id = ?", k.ID).
+		Update("kategori_id", nil).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete kategori"})
+		return
+	}
+	if err := tx.Delete(k).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete kategori"})
+		return
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete kategori"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "kategori deleted"})
+}
@@ -20,9 +20,19 @@ import (
 
 type createDiscountPayload struct {
 	Nama         string  `json:"nama_diskon" binding:"required"`
-	Persentase   float64 `json:"persentase_diskon" binding:"required,gte=0,lte=100"`
+	Persentase   float64 `json:"persentase_diskon" binding:"gte=0,lte=100"`
 	TanggalAwal  *string `json:"tanggal_awal,omitempty"`
 	TanggalAkhir *string `json:"tanggal_akhir,omitempty"`
+
+	Tipe       string   `json:"tipe,omitempty"`    // persen (default) | nominal
+	Nominal    float64  `json:"nominal,omitempty"` // Rupiah per item (tipe nominal)
+	MinBelanja float64  `json:"min_belanja,omitempty"`
+	Jenis      *string  `json:"jenis,omitempty"`    // makanan | minuman
+	MenuIDs    []string `json:"menu_ids,omitempty"` // public id menu stan ini
+	JamMulai   string   `json:"jam_mulai,omitempty"`
+	JamSelesai string   `json:"jam_selesai,omitempty"`
+	Prioritas  int      `json:"prioritas,omitempty"`
+	Stackable  bool     `json:"stackable,omitempty"`
 }
 
 type updateDiscountPayload struct {
@@ -30,6 +40,16 @@ type updateDiscountPayload struct {
 	Persentase   *float64 `json:"persentase_diskon,omitempty"`
 	TanggalAwal  *string  `json:"tanggal_awal,omitempty"`
 	TanggalAkhir *string  `json:"tanggal_akhir,omitempty"`
+
+	Tipe       *string   `json:"tipe,omitempty"`
+	Nominal    *float64  `json:"nominal,omitempty"`
+	MinBelanja *float64  `json:"min_belanja,omitempty"`
+	Jenis      *string   `json:"jenis,omitempty"` // "" = semua jenis
+	MenuIDs    *[]string `json:"menu_ids,omitempty"`
+	JamMulai   *string   `json:"jam_mulai,omitempty"`
+	JamSelesai *string   `json:"jam_selesai,omitempty"`
+	Prioritas  *int      `json:"prioritas,omitempty"`
+	Stackable  *bool     `json:"stackable,omitempty"`
 }
 
 //
@@ -69,6 +89,118 @@ func parseOptionalTime(s *string) (*time.Time, error) {
 	return nil, fmt.Errorf("unsupported date format")
 }
 
+// parseDiskonJenis "" → nil (semua jenis)
+func parseDiskonJenis(s string) (*app.MenuJenis, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "" {
+		return nil, nil
+	}
+	j := app.MenuJenis(s)
+	if j != app.JenisMakanan && j != app.JenisMinuman {
+		return nil, fmt.Errorf("invalid jenis, use makanan|minuman")
+	}
+	return &j, nil
+}
+
+// normalizeJam "9:5" → "09:05", "" tetap ""
+func normalizeJam(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", nil
+	}
+	m, err := app.ParseJam(s)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%02d:%02d", m/60, m%60), nil
+}
+
+// loadStanMenus public id → menu milik stan (semua harus ditemukan)
+func loadStanMenus(stanID uint, ids []string) ([]app.Menu, error) {
+	if len(ids) == 0 {
+		return []app.Menu{}, nil
+	}
+
+	var menus []app.Menu
+	if err := app.DB.
+		Where("stan_id = ? AND public_id IN ?", stanID, ids).
+		Find(&menus).Error; err != nil {
+		return nil, err
+	}
+
+	found := map[string]bool{}
+	for _, m := range menus {
+		found[m.PublicID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("menu %s not found in this stan", id)
+		}
+	}
+	return menus, nil
+}
+
+// validateDiskon aturan dasar diskon (dipakai create & update)
+func validateDiskon(d *app.Diskon) error {
+	switch d.Tipe {
+	case app.DiskonPersen:
+		if d.Persentase <= 0 || d.Persentase > 100 {
+			return fmt.Errorf("persentase_diskon must be between 0 and 100")
+		}
+	case app.DiskonNominal:
+		if d.Nominal <= 0 {
+			return fmt.Errorf("nominal must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid tipe, use persen|nominal")
+	}
+	if d.MinBelanja < 0 {
+		return fmt.Errorf("min_belanja must not be negative")
+	}
+	if (d.JamMulai == "") != (d.JamSelesai == "") {
+		return fmt.Errorf("jam_mulai and jam_selesai must be set together")
+	}
+	if d.JamMulai != "" && d.JamMulai == d.JamSelesai {
+		return fmt.Errorf("jam_mulai must differ from jam_selesai")
+	}
+	if d.TanggalAwal != nil && d.TanggalAkhir != nil && d.TanggalAwal.After(*d.TanggalAkhir) {
+		return fmt.Errorf("tanggal_awal must be before tanggal_akhir")
+	}
+	return nil
+}
+
+func discountJSON(d app.Diskon) gin.H {
+	menuIDs := make([]string, 0, len(d.Menus))
+	for _, m := range d.Menus {
+		menuIDs = append(menuIDs, m.PublicID)
+	}
+
+	return gin.H{
+		"diskon_id":         d.PublicID,
+		"nama_diskon":       d.Nama,
+		"tipe":              d.Tipe,
+		"persentase_diskon": d.Persentase,
+		"nominal":           d.Nominal,
+		"label":             d.Label(),
+
+		"min_belanja": d.MinBelanja,
+		"jenis":       d.Jenis,
+		"menu_ids":    menuIDs,
+		"jam_mulai":   d.JamMulai,
+		"jam_selesai": d.JamSelesai,
+		"prioritas":   d.Prioritas,
+		"stackable":   d.Stackable,
+
+		"tanggal_awal":  app.FormatISOOrNil(d.TanggalAwal),
+		"tanggal_akhir": app.FormatISOOrNil(d.TanggalAkhir),
+
+		"tanggal_awal_human":  app.FormatDateID(d.TanggalAwal, false),
+		"tanggal_awal_short":  app.FormatDateID(d.TanggalAwal, true),
+		"tanggal_akhir_human": app.FormatDateID(d.TanggalAkhir, false),
+		"tanggal_akhir_short": app.FormatDateID(d.TanggalAkhir, true),
+	}
+}
+
 //
 // =========================
 // CREATE
@@ -97,11 +229,33 @@ func AdminCreateDiscount(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal_akhir"})
 		return
 	}
-	if tAwal != nil && tAkhir != nil && tAwal.After(*tAkhir) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "tanggal_awal must be before tanggal_akhir"})
+
+	jenis, err := parseDiskonJenis(derefStr(p.Jenis))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	jamMulai, err := normalizeJam(p.JamMulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_mulai, use HH:MM"})
+		return
+	}
+	jamSelesai, err := normalizeJam(p.JamSelesai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_selesai, use HH:MM"})
+		return
+	}
+	menus, err := loadStanMenus(stan.ID, p.MenuIDs)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	tipe := app.DiskonTipe(strings.ToLower(strings.TrimSpace(p.Tipe)))
+	if tipe == "" {
+		tipe = app.DiskonPersen
+	}
+
 	d := app.Diskon{
 		PublicID:     uuid.NewString(),
 		StanID:       stan.ID,
@@ -109,6 +263,24 @@ func AdminCreateDiscount(c *gin.Context) {
 		Persentase:   p.Persentase,
 		TanggalAwal:  tAwal,
 		TanggalAkhir: tAkhir,
+
+		Tipe:       tipe,
+		Nominal:    p.Nominal,
+		MinBelanja: p.MinBelanja,
+		Jenis:      jenis,
+		Menus:      menus,
+		JamMulai:   jamMulai,
+		JamSelesai: jamSelesai,
+		Prioritas:  p.Prioritas,
+		Stackable:  p.Stackable,
+	}
+	if d.Tipe == app.DiskonNominal {
+		d.Persentase = 0
+	}
+
+	if err := validateDiskon(&d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
 	}
 
 	if err := app.DB.Create(&d).Error; err != nil {
@@ -116,14 +288,9 @@ func AdminCreateDiscount(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"diskon_id":         d.PublicID,
-		"stan_id":           stan.PublicID,
-		"nama_diskon":       d.Nama,
-		"persentase_diskon": d.Persentase,
-		"tanggal_awal":      app.FormatISOOrNil(d.TanggalAwal),
-		"tanggal_akhir":     app.FormatISOOrNil(d.TanggalAkhir),
-	})
+	out := discountJSON(d)
+	out["stan_id"] = stan.PublicID
+	c.JSON(http.StatusCreated, out)
 
 }
 
@@ -133,28 +300,84 @@ func AdminCreateDiscount(c *gin.Context) {
 // =========================
 //
 
+// findStanDiscount diskon milik stan yang login (stan lain → 404)
+func findStanDiscount(c *gin.Context, stan *app.Stan) (*app.Diskon, bool) {
+	var d app.Diskon
+	if err := app.DB.
+		Preload("Menus").
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		First(&d).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "discount not found"})
+		return nil, false
+	}
+	return &d, true
+}
+
 func AdminListDiscounts(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
 	var diskons []app.Diskon
-	if err := app.DB.Order("created_at DESC").Find(&diskons).Error; err != nil {
+	if err := app.DB.
+		Preload("Menus").
+		Where("stan_id = ?", stan.ID).
+		Order("created_at DESC").
+		Find(&diskons).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list discounts"})
 		return
 	}
 
 	out := make([]gin.H, 0, len(diskons))
 	for _, d := range diskons {
-		out = append(out, gin.H{
-			"diskon_id":         d.PublicID,
-			"nama_diskon":       d.Nama,
-			"persentase_diskon": d.Persentase,
+		out = append(out, discountJSON(d))
+	}
 
-			"tanggal_awal":  app.FormatISOOrNil(d.TanggalAwal),
-			"tanggal_akhir": app.FormatISOOrNil(d.TanggalAkhir),
+	c.JSON(http.StatusOK, gin.H{"discounts": out})
+}
+
+// GET /api/admin/system/discounts (super admin)
+// Semua diskon lintas stan (read-only). optional: ?stan_id=<stan_public_id>
+func AdminListAllDiscounts(c *gin.Context) {
+	q := app.DB.Preload("Menus")
 
-			"tanggal_awal_human":  app.FormatDateID(d.TanggalAwal, false),
-			"tanggal_awal_short":  app.FormatDateID(d.TanggalAwal, true),
-			"tanggal_akhir_human": app.FormatDateID(d.TanggalAkhir, false),
-			"tanggal_akhir_short": app.FormatDateID(d.TanggalAkhir, true),
-		})
+	if pub := c.Query("stan_id"); pub != "" {
+		var stan app.Stan
+		if err := app.DB.Where("public_id = ?", pub).First(&stan).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stan not found"})
+			return
+		}
+		q = q.Where("stan_id = ?", stan.ID)
+	}
+
+	var diskons []app.Diskon
+	if err := q.Order("stan_id ASC, created_at DESC").Find(&diskons).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list discounts"})
+		return
+	}
+
+	stanIDs := make([]uint, 0, len(diskons))
+	for _, d := range diskons {
+		stanIDs = append(stanIDs, d.StanID)
+	}
+	var stans []app.Stan
+	if len(stanIDs) > 0 {
+		app.DB.Where("id IN ?", stanIDs).Find(&stans)
+	}
+	stanByID := map[uint]app.Stan{}
+	for _, st := range stans {
+		stanByID[st.ID] = st
+	}
+
+	out := make([]gin.H, 0, len(diskons))
+	for _, d := range diskons {
+		item := discountJSON(d)
+		item["stan"] = gin.H{
+			"id":   stanByID[d.StanID].PublicID,
+			"name": stanByID[d.StanID].NamaStan,
+		}
+		out = append(out, item)
 	}
 
 	c.JSON(http.StatusOK, gin.H{"discounts": out})
@@ -167,21 +390,17 @@ func AdminListDiscounts(c *gin.Context) {
 //
 
 func AdminGetDiscount(c *gin.Context) {
-	pub := c.Param("id")
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
 
-	var d app.Diskon
-	if err := app.DB.Where("public_id = ?", pub).First(&d).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "discount not found"})
+	d, ok := findStanDiscount(c, stan)
+	if !ok {
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"diskon_id":         d.PublicID,
-		"nama_diskon":       d.Nama,
-		"persentase_diskon": d.Persentase,
-		"tanggal_awal":      d.TanggalAwal,
-		"tanggal_akhir":     d.TanggalAkhir,
-	})
+	c.JSON(http.StatusOK, discountJSON(*d))
 }
 
 //
@@ -191,11 +410,13 @@ func AdminGetDiscount(c *gin.Context) {
 //
 
 func AdminUpdateDiscount(c *gin.Context) {
-	pub := c.Param("id")
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
 
-	var d app.Diskon
-	if err := app.DB.Where("public_id = ?", pub).First(&d).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "discount not found"})
+	d, ok := findStanDiscount(c, stan)
+	if !ok {
 		return
 	}
 
@@ -228,10 +449,74 @@ func AdminUpdateDiscount(c *gin.Context) {
 		d.TanggalAkhir = t
 	}
 
-	if err := app.DB.Save(&d).Error; err != nil {
+	if p.Tipe != nil {
+		d.Tipe = app.DiskonTipe(strings.ToLower(strings.TrimSpace(*p.Tipe)))
+	}
+	if p.Nominal != nil {
+		d.Nominal = *p.Nominal
+	}
+	if p.MinBelanja != nil {
+		d.MinBelanja = *p.MinBelanja
+	}
+	if p.Jenis != nil {
+		jenis, err := parseDiskonJenis(*p.Jenis)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		d.Jenis = jenis
+	}
+	if p.JamMulai != nil {
+		jam, err := normalizeJam(*p.JamMulai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_mulai, use HH:MM"})
+			return
+		}
+		d.JamMulai = jam
+	}
+	if p.JamSelesai != nil {
+		jam, err := normalizeJam(*p.JamSelesai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_selesai, use HH:MM"})
+			return
+		}
+		d.JamSelesai = jam
+	}
+	if p.Prioritas != nil {
+		d.Prioritas = *p.Prioritas
+	}
+	if p.Stackable != nil {
+		d.Stackable = *p.Stackable
+	}
+
+	if d.Tipe == app.DiskonNominal {
+		d.Persentase = 0
+	}
+	if err := validateDiskon(d); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var menus []app.Menu
+	if p.MenuIDs != nil {
+		m, err := loadStanMenus(stan.ID, *p.MenuIDs)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		menus = m
+	}
+
+	if err := app.DB.Omit("Menus").Save(d).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update discount"})
 		return
 	}
+	if p.MenuIDs != nil {
+		if err := app.DB.Model(d).Association("Menus").Replace(menus); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update discount menus"})
+			return
+		}
+	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "discount updated"})
 }
@@ -243,18 +528,31 @@ func AdminUpdateDiscount(c *gin.Context) {
 //
 
 func AdminDeleteDiscount(c *gin.Context) {
-	pub := c.Param("id")
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
 
-	var d app.Diskon
-	if err := app.DB.Where("public_id = ?", pub).First(&d).Error; err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": "discount not found"})
+	d, ok := findStanDiscount(c, stan)
+	if !ok {
 		return
 	}
 
-	if err := app.DB.Delete(&d).Error; err != nil {
+	if err := app.DB.Model(d).Association("Menus").Clear(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete discount"})
+		return
+	}
+	if err := app.DB.Delete(d).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete discount"})
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "discount deleted"})
 }
+
+func derefStr(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
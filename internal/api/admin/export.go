@@ -0,0 +1,429 @@
+package admin
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/xuri/excelize/v2"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// EXPORT HELPERS (CSV / XLSX)
+// =========================
+//
+// CSV : angka polos (tanpa "Rp") supaya bisa langsung di-SUM di Excel.
+// XLSX: angka numerik + format Rupiah, ada sheet "Ringkasan".
+
+const (
+	xlsxContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	rupiahNumFmt    = `"Rp" #,##0;[Red]-"Rp" #,##0`
+)
+
+// periodLabel label periode untuk header laporan (tanggal WIB)
+func periodLabel(from, to *time.Time) string {
+	if from == nil && to == nil {
+		return "Semua periode"
+	}
+	start, end := "awal", "sekarang"
+	if from != nil {
+		start = app.FormatDateID(from, false)
+	}
+	if to != nil {
+		last := to.Add(-time.Second) // to eksklusif → hari terakhir
+		end = app.FormatDateID(&last, false)
+	}
+	return start + " - " + end
+}
+
+// exportFilename mis. "rekap-kantin-bu-siti-2026-01.xlsx"
+func exportFilename(prefix string, stan app.Stan, suffix, ext string) string {
+	name := prefix + "-" + filenameSlug(stan.NamaStan)
+	if suffix != "" {
+		name += "-" + suffix
+	}
+	return name + "." + ext
+}
+
+// filenameSlug hanya [a-z0-9-] (aman untuk header Content-Disposition),
+// karakter lain jadi "-", mis. "Kantin Bu Siti's" → "kantin-bu-siti-s"
+func filenameSlug(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		if !dash && b.Len() > 0 {
+			b.WriteByte('-')
+			dash = true
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "stan"
+	}
+	return slug
+}
+
+func rangeSuffix(from, to *time.Time) string {
+	loc := app.JakartaLoc()
+	parts := []string{}
+	if from != nil {
+		parts = append(parts, from.In(loc).Format("20060102"))
+	}
+	if to != nil {
+		parts = append(parts, to.Add(-time.Second).In(loc).Format("20060102"))
+	}
+	return strings.Join(parts, "_")
+}
+
+func setDownloadHeaders(c *gin.Context, contentType, filename string) {
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+	c.Status(http.StatusOK)
+}
+
+// csvText sel teks bebas (nama siswa / menu) untuk CSV. Teks yang diawali
+// = + - @ (atau tab / CR) dibaca Excel sebagai formula → diberi awalan '.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func tanggalWIB(t time.Time) string {
+	return t.In(app.JakartaLoc()).Format("2006-01-02 15:04")
+}
+
+func money(v float64) string {
+	return fmt.Sprintf("%.2f", app.Round2(v))
+}
+
+// xlsxSheet penulis baris sederhana untuk 1 sheet excelize
+type xlsxSheet struct {
+	f      *excelize.File
+	name   string
+	row    int
+	bold   int
+	rupiah int
+}
+
+func newXLSXSheet(f *excelize.File, name string, bold, rupiah int) *xlsxSheet {
+	if idx, _ := f.GetSheetIndex(name); idx == -1 {
+		f.NewSheet(name)
+	}
+	return &xlsxSheet{f: f, name: name, row: 1, bold: bold, rupiah: rupiah}
+}
+
+// write menulis 1 baris. moneyCols = index kolom (0-based) berformat Rupiah.
+func (s *xlsxSheet) write(values []interface{}, moneyCols ...int) {
+	cell, _ := excelize.CoordinatesToCellName(1, s.row)
+	s.f.SetSheetRow(s.name, cell, &values)
+	for _, col := range moneyCols {
+		ref, _ := excelize.CoordinatesToCellName(col+1, s.row)
+		s.f.SetCellStyle(s.name, ref, ref, s.rupiah)
+	}
+	s.row++
+}
+
+// header menulis baris tebal
+func (s *xlsxSheet) header(values ...interface{}) {
+	s.write(values)
+	first, _ := excelize.CoordinatesToCellName(1, s.row-1)
+	last, _ := excelize.CoordinatesToCellName(len(values), s.row-1)
+	s.f.SetCellStyle(s.name, first, last, s.bold)
+}
+
+func (s *xlsxSheet) blank() { s.row++ }
+
+// newReportWorkbook membuat workbook + style bold & Rupiah
+func newReportWorkbook() (*excelize.File, int, int) {
+	f := excelize.NewFile()
+	bold, _ := f.NewStyle(&excelize.Style{Font: &excelize.Font{Bold: true}})
+	fmtStr := rupiahNumFmt
+	rupiah, _ := f.NewStyle(&excelize.Style{CustomNumFmt: &fmtStr})
+	return f, bold, rupiah
+}
+
+func sendWorkbook(c *gin.Context, f *excelize.File, filename string) {
+	defer f.Close()
+	// sheet default "Sheet1" dibuang jika tidak dipakai
+	if idx, _ := f.GetSheetIndex("Sheet1"); idx != -1 && f.SheetCount > 1 {
+		f.DeleteSheet("Sheet1")
+	}
+	f.SetActiveSheet(0)
+
+	setDownloadHeaders(c, xlsxContentType, filename)
+	if err := f.Write(c.Writer); err != nil {
+		c.Error(err)
+	}
+}
+
+//
+// =========================
+// REKAP EXPORT
+// =========================
+//
+
+func rekapHeader(perDetail bool) []string {
+	if perDetail {
+		return []string{"Tanggal", "Transaksi ID", "Nama Siswa", "Metode Bayar", "Menu", "Qty", "Harga Normal (Rp)", "Harga Beli (Rp)", "Diskon (Rp)", "Subtotal (Rp)", "Potongan Voucher (Rp)"}
+	}
+	return []string{"No", "Tanggal", "Transaksi ID", "Nama Siswa", "Metode Bayar", "Jumlah Item", "Total (Rp)"}
+}
+
+// rekapRows baris data rekap; moneyCols = kolom nominal
+// Mode detail: potongan voucher (per transaksi) ditulis di baris item pertama.
+func rekapRows(rd *rekapData, perDetail bool) (rows [][]interface{}, moneyCols []int) {
+	if perDetail {
+		for _, trx := range rd.Transaksis {
+			voucher := app.Round2(trxVoucher(trx))
+			for i, d := range trx.Details {
+				potongan := 0.0
+				if i == 0 {
+					potongan = voucher
+				}
+				rows = append(rows, []interface{}{
+					tanggalWIB(trx.CreatedAt),
+					trx.PublicID,
+					rd.SiswaNames[trx.SiswaID],
+					string(trx.PaymentMethod),
+					d.MenuName(),
+					d.Qty,
+					app.Round2(d.OriginalPrice()),
+					app.Round2(d.HargaBeli),
+					app.Round2(d.DiskonAmount()),
+					app.Round2(d.Subtotal()),
+					potongan,
+				})
+			}
+		}
+		return rows, []int{6, 7, 8, 9, 10}
+	}
+
+	for i, trx := range rd.Transaksis {
+		items := 0
+		for _, d := range trx.Details {
+			items += d.Qty
+		}
+		rows = append(rows, []interface{}{
+			i + 1,
+			tanggalWIB(trx.CreatedAt),
+			trx.PublicID,
+			rd.SiswaNames[trx.SiswaID],
+			string(trx.PaymentMethod),
+			items,
+			app.Round2(trxTotal(trx)),
+		})
+	}
+	return rows, []int{6}
+}
+
+// rekapFooter baris total di bawah tabel rekap (sel nil = kosong).
+// Mode detail: TOTAL subtotal & potongan voucher, lalu TOTAL PEMASUKAN
+// (subtotal - voucher) di kolom subtotal supaya tabel bisa dicocokkan.
+func rekapFooter(rd *rekapData, perDetail bool) (rows [][]interface{}, moneyCols []int) {
+	width := len(rekapHeader(perDetail))
+	if !perDetail {
+		total := make([]interface{}, width)
+		total[0] = "TOTAL"
+		total[width-1] = app.Round2(rd.Total)
+		return [][]interface{}{total}, []int{width - 1}
+	}
+
+	subtotalCol, voucherCol := width-2, width-1
+	items := make([]interface{}, width)
+	items[0] = "TOTAL"
+	items[subtotalCol] = app.Round2(rd.ItemsTotal)
+	items[voucherCol] = app.Round2(rd.Voucher)
+
+	net := make([]interface{}, width)
+	net[0] = "TOTAL PEMASUKAN"
+	net[subtotalCol] = app.Round2(rd.Total)
+
+	return [][]interface{}{items, net}, []int{subtotalCol, voucherCol}
+}
+
+// writeRekapCSV 1 file CSV: baris data lalu baris TOTAL
+func writeRekapCSV(c *gin.Context, rd *rekapData, perDetail bool) {
+	filename := exportFilename("rekap", rd.Stan, rangeSuffix(rd.From, rd.To), "csv")
+	setDownloadHeaders(c, "text/csv; charset=utf-8", filename)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(rekapHeader(perDetail))
+
+	rows, moneyCols := rekapRows(rd, perDetail)
+	for _, r := range rows {
+		rec := make([]string, len(r))
+		for i, v := range r {
+			if str, ok := v.(string); ok {
+				rec[i] = csvText(str)
+				continue
+			}
+			rec[i] = fmt.Sprint(v)
+		}
+		for _, mc := range moneyCols {
+			rec[mc] = money(r[mc].(float64))
+		}
+		w.Write(rec)
+	}
+
+	footer, _ := rekapFooter(rd, perDetail)
+	for _, r := range footer {
+		rec := make([]string, len(r))
+		for i, v := range r {
+			switch v := v.(type) {
+			case nil:
+			case float64:
+				rec[i] = money(v)
+			default:
+				rec[i] = fmt.Sprint(v)
+			}
+		}
+		w.Write(rec)
+	}
+
+	w.Flush()
+}
+
+// writeRekapXLSX sheet "Transaksi"/"Detail" + "Ringkasan"
+func writeRekapXLSX(c *gin.Context, rd *rekapData, perDetail bool) {
+	f, bold, rupiah := newReportWorkbook()
+
+	// ----- Ringkasan -----
+	sum := newXLSXSheet(f, "Ringkasan", bold, rupiah)
+	sum.header("Rekap Transaksi")
+	sum.write([]interface{}{"Stan", rd.Stan.NamaStan})
+	sum.write([]interface{}{"Pemilik", rd.Stan.NamaPemilik})
+	sum.write([]interface{}{"Periode", periodLabel(rd.From, rd.To)})
+	sum.write([]interface{}{"Total Transaksi", len(rd.Transaksis)})
+	sum.write([]interface{}{"Subtotal Item", app.Round2(rd.ItemsTotal)}, 1)
+	sum.write([]interface{}{"Potongan Voucher", app.Round2(rd.Voucher)}, 1)
+	sum.write([]interface{}{"Total Pemasukan", app.Round2(rd.Total)}, 1)
+	sum.write([]interface{}{"Dibuat", tanggalWIB(time.Now()) + " WIB"})
+	f.SetColWidth("Ringkasan", "A", "A", 20)
+	f.SetColWidth("Ringkasan", "B", "B", 32)
+
+	// ----- Data -----
+	sheetName := "Transaksi"
+	if perDetail {
+		sheetName = "Detail"
+	}
+	data := newXLSXSheet(f, sheetName, bold, rupiah)
+	head := rekapHeader(perDetail)
+	hv := make([]interface{}, len(head))
+	for i, h := range head {
+		hv[i] = h
+	}
+	data.header(hv...)
+
+	rows, moneyCols := rekapRows(rd, perDetail)
+	for _, r := range rows {
+		data.write(r, moneyCols...)
+	}
+
+	footer, footerMoney := rekapFooter(rd, perDetail)
+	for _, r := range footer {
+		data.write(r, footerMoney...)
+	}
+
+	lastCol, _ := excelize.ColumnNumberToName(len(head))
+	f.SetColWidth(sheetName, "A", lastCol, 18)
+
+	filename := exportFilename("rekap", rd.Stan, rangeSuffix(rd.From, rd.To), "xlsx")
+	sendWorkbook(c, f, filename)
+}
+
+//
+// =========================
+// MONTHLY REPORT EXPORT
+// =========================
+//
+
+// writeMonthlyCSV bagian per hari, per menu, lalu ringkasan (dipisah baris kosong)
+func writeMonthlyCSV(c *gin.Context, stan app.Stan, month string, rep *stanReport) {
+	filename := exportFilename("laporan", stan, month, "csv")
+	setDownloadHeaders(c, "text/csv; charset=utf-8", filename)
+
+	w := csv.NewWriter(c.Writer)
+
+	w.Write([]string{"Tanggal", "Total Order", "Pendapatan (Rp)"})
+	for _, d := range rep.Days {
+		w.Write([]string{d.Date, fmt.Sprint(d.Orders), money(d.Revenue)})
+	}
+	w.Write([]string{})
+
+	w.Write([]string{"Menu", "Qty", "Pendapatan (Rp)", "Diskon (Rp)"})
+	for _, m := range rep.Menus {
+		w.Write([]string{csvText(m.Nama), fmt.Sprint(m.Qty), money(m.Revenue), money(m.Discount)})
+	}
+	w.Write([]string{})
+
+	s := rep.Summary
+	w.Write([]string{"Ringkasan", month})
+	w.Write([]string{"Total Order", fmt.Sprint(s.Orders)})
+	w.Write([]string{"Item Terjual", fmt.Sprint(s.ItemsSold)})
+	w.Write([]string{"Harga Normal (Rp)", money(s.Gross)})
+	w.Write([]string{"Total Diskon (Rp)", money(s.Discount)})
+	w.Write([]string{"Potongan Voucher (Rp)", money(s.Voucher)})
+	w.Write([]string{"Pendapatan (Rp)", money(s.Revenue)})
+	w.Write([]string{"Rata-rata Transaksi (Rp)", money(s.AvgBasket)})
+
+	w.Flush()
+}
+
+// writeMonthlyXLSX sheet "Ringkasan", "Per Hari", "Per Menu", "Transaksi"
+func writeMonthlyXLSX(c *gin.Context, stan app.Stan, month string, rep *stanReport) {
+	f, bold, rupiah := newReportWorkbook()
+	s := rep.Summary
+
+	sum := newXLSXSheet(f, "Ringkasan", bold, rupiah)
+	sum.header("Laporan Bulanan")
+	sum.write([]interface{}{"Stan", stan.NamaStan})
+	sum.write([]interface{}{"Pemilik", stan.NamaPemilik})
+	sum.write([]interface{}{"Bulan", month})
+	sum.blank()
+	sum.write([]interface{}{"Total Order", s.Orders})
+	sum.write([]interface{}{"Item Terjual", s.ItemsSold})
+	sum.write([]interface{}{"Harga Normal", app.Round2(s.Gross)}, 1)
+	sum.write([]interface{}{"Total Diskon", app.Round2(s.Discount)}, 1)
+	sum.write([]interface{}{"Potongan Voucher", app.Round2(s.Voucher)}, 1)
+	sum.write([]interface{}{"Pendapatan", app.Round2(s.Revenue)}, 1)
+	sum.write([]interface{}{"Rata-rata Transaksi", app.Round2(s.AvgBasket)}, 1)
+	f.SetColWidth("Ringkasan", "A", "A", 22)
+	f.SetColWidth("Ringkasan", "B", "B", 28)
+
+	days := newXLSXSheet(f, "Per Hari", bold, rupiah)
+	days.header("Tanggal", "Total Order", "Pendapatan")
+	for _, d := range rep.Days {
+		days.write([]interface{}{d.Date, d.Orders, app.Round2(d.Revenue)}, 2)
+	}
+	f.SetColWidth("Per Hari", "A", "C", 16)
+
+	menus := newXLSXSheet(f, "Per Menu", bold, rupiah)
+	menus.header("Menu", "Qty", "Pendapatan", "Diskon")
+	for _, m := range rep.Menus {
+		menus.write([]interface{}{m.Nama, m.Qty, app.Round2(m.Revenue), app.Round2(m.Discount)}, 2, 3)
+	}
+	f.SetColWidth("Per Menu", "A", "A", 28)
+	f.SetColWidth("Per Menu", "B", "D", 16)
+
+	trx := newXLSXSheet(f, "Transaksi", bold, rupiah)
+	trx.header("Tanggal", "Transaksi ID", "Metode Bayar", "Total")
+	for _, t := range rep.Transaksis {
+		trx.write([]interface{}{tanggalWIB(t.CreatedAt), t.PublicID, string(t.PaymentMethod), app.Round2(trxTotal(t))}, 3)
+	}
+	f.SetColWidth("Transaksi", "A", "D", 20)
+
+	sendWorkbook(c, f, exportFilename("laporan", stan, month, "xlsx"))
+}
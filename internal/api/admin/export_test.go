@@ -0,0 +1,177 @@
+package admin
+
+import (
+	"encoding/csv"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// seedRekap 2 order sampai: 1 tanpa voucher (15.000), 1 dengan voucher
+// 5.000 dari subtotal 20.000 → pemasukan bersih 30.000
+func seedRekap(t *testing.T) *rekapData {
+	t.Helper()
+	db := apptest.OpenDB(t)
+	stan := apptest.SeedStan(t, db, "Kantin Rekap")
+	_, siswa := apptest.SeedSiswa(t, db, 0)
+	nasi := apptest.SeedMenu(t, db, stan.ID, "Nasi Goreng", 10000)
+	teh := apptest.SeedMenu(t, db, stan.ID, "Es Teh", 5000)
+
+	orders := []app.Transaksi{
+		{
+			Details: []app.DetailTransaksi{
+				{MenuID: nasi.ID, Qty: 1, HargaBeli: 10000, HargaNormal: 10000},
+				{MenuID: teh.ID, Qty: 1, HargaBeli: 5000, HargaNormal: 5000},
+			},
+		},
+		{
+			VoucherKode:     "HEMAT",
+			VoucherPotongan: 5000,
+			Details: []app.DetailTransaksi{
+				{MenuID: nasi.ID, Qty: 2, HargaBeli: 8000, HargaNormal: 10000},
+				{MenuID: teh.ID, Qty: 1, HargaBeli: 4000, HargaNormal: 5000},
+			},
+		},
+	}
+	for i := range orders {
+		orders[i].StanID = stan.ID
+		orders[i].SiswaID = siswa.ID
+		orders[i].Status = app.StatusSampai
+		orders[i].PaymentMethod = app.PaymentWallet
+		orders[i].PaymentStatus = app.PaymentPaid
+		if err := db.Create(&orders[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rd, err := loadRekap(*stan, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rd
+}
+
+func TestRekapExportAddsUp(t *testing.T) {
+	rd := seedRekap(t)
+	if rd.ItemsTotal != 35000 || rd.Voucher != 5000 || rd.Total != 30000 {
+		t.Fatalf("rekap = items %v voucher %v total %v, want 35000/5000/30000", rd.ItemsTotal, rd.Voucher, rd.Total)
+	}
+
+	for _, perDetail := range []bool{false, true} {
+		rows, _ := rekapRows(rd, perDetail)
+		footer, _ := rekapFooter(rd, perDetail)
+		width := len(rekapHeader(perDetail))
+
+		if !perDetail {
+			var sum float64
+			for _, r := range rows {
+				sum += r[width-1].(float64)
+			}
+			if got := footer[0][width-1].(float64); sum != got || got != rd.Total {
+				t.Errorf("per transaksi: rows %v, footer %v, want %v", sum, got, rd.Total)
+			}
+			continue
+		}
+
+		var subtotal, voucher float64
+		for _, r := range rows {
+			subtotal += r[width-2].(float64)
+			voucher += r[width-1].(float64)
+		}
+		if subtotal != footer[0][width-2].(float64) || voucher != footer[0][width-1].(float64) {
+			t.Errorf("detail: footer TOTAL %v/%v, rows %v/%v", footer[0][width-2], footer[0][width-1], subtotal, voucher)
+		}
+		if net := footer[1][width-2].(float64); net != subtotal-voucher || net != rd.Total {
+			t.Errorf("detail: TOTAL PEMASUKAN %v, want %v", net, subtotal-voucher)
+		}
+	}
+}
+
+func TestWriteRekapCSVDetail(t *testing.T) {
+	rd := seedRekap(t)
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	writeRekapCSV(c, rd, true)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	head := records[0]
+	if head[len(head)-1] != "Potongan Voucher (Rp)" {
+		t.Fatalf("header = %v", head)
+	}
+
+	last := records[len(records)-1]
+	if last[0] != "TOTAL PEMASUKAN" {
+		t.Fatalf("last row = %v", last)
+	}
+	if net, _ := strconv.ParseFloat(last[len(last)-2], 64); net != 30000 {
+		t.Errorf("TOTAL PEMASUKAN = %q, want 30000.00", last[len(last)-2])
+	}
+}
+
+func TestExportFilename(t *testing.T) {
+	cases := []struct {
+		nama string
+		want string
+	}{
+		{"Kantin Bu Siti", "rekap-kantin-bu-siti-2026-01.pdf"},
+		{`Kantin "Bu" Siti's`, "rekap-kantin-bu-siti-s-2026-01.pdf"},
+		{"  Soto; Ayam\r\nX  ", "rekap-soto-ayam-x-2026-01.pdf"},
+		{"Warung Café 99", "rekap-warung-caf-99-2026-01.pdf"},
+		{"!!!", "rekap-stan-2026-01.pdf"},
+	}
+	for _, tc := range cases {
+		if got := exportFilename("rekap", app.Stan{NamaStan: tc.nama}, "2026-01", "pdf"); got != tc.want {
+			t.Errorf("exportFilename(%q) = %q, want %q", tc.nama, got, tc.want)
+		}
+	}
+}
+
+func TestCSVTextEscapesFormulas(t *testing.T) {
+	cases := map[string]string{
+		"Nasi Goreng":       "Nasi Goreng",
+		"=HYPERLINK(\"x\")": "'=HYPERLINK(\"x\")",
+		"+62812":            "'+62812",
+		"-1+1":              "'-1+1",
+		"@SUM(A1)":          "'@SUM(A1)",
+		"\t=1":              "'\t=1",
+		"a=b":               "a=b",
+		"":                  "",
+	}
+	for in, want := range cases {
+		if got := csvText(in); got != want {
+			t.Errorf("csvText(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestWriteRekapCSVEscapesNames(t *testing.T) {
+	rd := seedRekap(t)
+	for id := range rd.SiswaNames {
+		rd.SiswaNames[id] = "=cmd|' /C calc'!A0"
+	}
+	rd.Transaksis[0].Details[0].NamaMenu = "@SUM(1+1)"
+
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	writeRekapCSV(c, rd, true)
+
+	records, err := csv.NewReader(w.Body).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := records[1]
+	if row[2] != "'=cmd|' /C calc'!A0" || row[4] != "'@SUM(1+1)" {
+		t.Errorf("nama siswa / menu = %q / %q, want prefixed with '", row[2], row[4])
+	}
+}
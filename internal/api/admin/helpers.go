@@ -4,6 +4,7 @@ import (
 	"errors"
 
 	"net/http"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/samudsamudra/UKK_kantin/internal/app"
@@ -70,3 +71,36 @@ func requireStanOrAbort(c *gin.Context) (*app.Stan, bool) {
 	}
 	return stan, true
 }
+
+// siswaNamesByID map siswa.id → nama (1 query)
+func siswaNamesByID(ids []uint) map[uint]string {
+	names := map[uint]string{}
+	if len(ids) == 0 {
+		return names
+	}
+
+	var siswas []app.Siswa
+	app.DB.Select("id", "nama").Where("id IN ?", ids).Find(&siswas)
+	for _, s := range siswas {
+		names[s.ID] = s.Nama
+	}
+	return names
+}
+
+// nullIfEmpty "" → null di JSON
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// likeEscaper meng-escape wildcard LIKE dari input user (pakai ESCAPE '!',
+// karena backslash tidak portable antara MySQL & SQLite)
+var likeEscaper = strings.NewReplacer("!", "!!", "%", "!%", "_", "!_")
+
+// likeContains pola "%s%" untuk `LIKE ? ESCAPE '!'`; % dan _ dari user
+// dicocokkan apa adanya, bukan sebagai wildcard
+func likeContains(s string) string {
+	return "%" + likeEscaper.Replace(s) + "%"
+}
@@ -0,0 +1,62 @@
+package admin
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// seedAdminStan stan milik user admin_stan baru
+func seedAdminStan(t *testing.T, db *gorm.DB) (*app.User, *app.Stan) {
+	t.Helper()
+	admin := app.User{Email: "admin-" + t.Name() + "@stan.test", PasswordHash: "-", Role: app.RoleAdminStan}
+	if err := db.Create(&admin).Error; err != nil {
+		t.Fatal(err)
+	}
+	stan := apptest.SeedStan(t, db, "Stan "+t.Name())
+	db.Model(stan).UpdateColumn("user_id", admin.ID)
+	stan.UserID = admin.ID
+	return &admin, stan
+}
+
+// callHandler menjalankan handler langsung sebagai user (user_id di context).
+// target boleh berisi query string; params: pasangan key, value.
+func callHandler(t *testing.T, h gin.HandlerFunc, userID uint, method, target string, body interface{}, params ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	var buf bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&buf).Encode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(method, target, &buf)
+	c.Request.Header.Set("Content-Type", "application/json")
+	for i := 0; i+1 < len(params); i += 2 {
+		c.Params = append(c.Params, gin.Param{Key: params[i], Value: params[i+1]})
+	}
+	c.Set("user_id", userID)
+
+	h(c)
+	return w
+}
+
+func decodeBody(t *testing.T, w *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+	var out map[string]interface{}
+	if err := json.Unmarshal(w.Body.Bytes(), &out); err != nil {
+		t.Fatalf("decode %q: %v", w.Body.String(), err)
+	}
+	return out
+}
@@ -0,0 +1,138 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// MENU IMAGE (ADMIN STAN)
+// =========================
+//
+
+// menuImageJSON URL gambar untuk response (null jika belum ada)
+func menuImageJSON(m app.Menu) gin.H {
+	return gin.H{
+		"image_url":     nullIfEmpty(m.ImageURL()),
+		"thumbnail_url": nullIfEmpty(m.ThumbURL()),
+	}
+}
+
+// POST /api/admin/menus/:id/image
+// multipart/form-data, field "image" (jpeg | png | webp, maks MENU_IMAGE_MAX_MB)
+// Gambar lama (jika ada) diganti.
+func AdminUploadMenuImage(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var menu app.Menu
+	if err := app.DB.
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		First(&menu).Error; err != nil {
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return
+	}
+
+	maxBytes := app.MenuImageMaxBytes()
+	// +1MB untuk overhead multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+(1<<20))
+
+	file, _, err := c.Request.FormFile("image")
+	if err != nil {
+		var tooBig *http.MaxBytesError
+		if errors.As(err, &tooBig) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "image too large", "max_bytes": maxBytes})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "image is required (multipart field \"image\")"})
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "failed to read image"})
+		return
+	}
+
+	img, err := app.ProcessMenuImage(data)
+	switch {
+	case errors.Is(err, app.ErrImageTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": err.Error(), "max_bytes": maxBytes})
+		return
+	case errors.Is(err, app.ErrImageUnsupported):
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": err.Error()})
+		return
+	case err != nil:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx := c.Request.Context()
+	imageKey, thumbKey, err := app.SaveMenuImage(ctx, menu, img)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to store image"})
+		return
+	}
+
+	oldImage, oldThumb := menu.ImageKey, menu.ThumbKey
+	if err := app.DB.Model(&menu).Updates(map[string]interface{}{
+		"image_key": imageKey,
+		"thumb_key": thumbKey,
+	}).Error; err != nil {
+		app.DeleteMenuImageFiles(ctx, imageKey, thumbKey)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update menu"})
+		return
+	}
+	app.DeleteMenuImageFiles(ctx, oldImage, oldThumb)
+
+	out := menuImageJSON(menu)
+	out["message"] = "menu image uploaded"
+	out["menu_id"] = menu.PublicID
+	out["width"] = img.Width
+	out["height"] = img.Height
+	c.JSON(http.StatusOK, out)
+}
+
+// DELETE /api/admin/menus/:id/image
+func AdminDeleteMenuImage(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var menu app.Menu
+	if err := app.DB.
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		First(&menu).Error; err != nil {
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return
+	}
+
+	if menu.ImageKey == "" && menu.ThumbKey == "" {
+		c.JSON(http.StatusOK, gin.H{"message": "menu has no image"})
+		return
+	}
+
+	oldImage, oldThumb := menu.ImageKey, menu.ThumbKey
+	if err := app.DB.Model(&menu).Updates(map[string]interface{}{
+		"image_key": "",
+		"thumb_key": "",
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update menu"})
+		return
+	}
+	app.DeleteMenuImageFiles(c.Request.Context(), oldImage, oldThumb)
+
+	c.JSON(http.StatusOK, gin.H{"message": "menu image deleted"})
+}
@@ -1,6 +1,7 @@
 package admin
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -19,6 +20,14 @@ type createMenuPayload struct {
 	Harga       float64 `json:"harga" binding:"required,gt=0"`
 	Jenis       string  `json:"jenis" binding:"required,oneof=makanan minuman"`
 	Deskripsi   string  `json:"deskripsi,omitempty"`
+
+	// stok: kosong / null = tidak terbatas
+	Stok        *int  `json:"stok,omitempty" binding:"omitempty,gte=0"`
+	IsAvailable *bool `json:"is_available,omitempty"`
+
+	KategoriID string   `json:"kategori_id,omitempty"` // public id kategori stan ini
+	Tags       []string `json:"tags,omitempty"`        // bebas, mis. pedas, vegetarian
+	Alergen    []string `json:"alergen,omitempty"`     // kacang | susu | seafood | gluten
 }
 
 type updateMenuPayload struct {
@@ -26,6 +35,113 @@ type updateMenuPayload struct {
 	Harga       *float64 `json:"harga,omitempty"`
 	Jenis       *string  `json:"jenis,omitempty"`
 	Deskripsi   *string  `json:"deskripsi,omitempty"`
+
+	Stok          *int  `json:"stok,omitempty" binding:"omitempty,gte=0"`
+	StokUnlimited *bool `json:"stok_unlimited,omitempty"` // true → stok = NULL
+	IsAvailable   *bool `json:"is_available,omitempty"`
+
+	KategoriID *string   `json:"kategori_id,omitempty"` // "" = tanpa kategori
+	Tags       *[]string `json:"tags,omitempty"`        // menggantikan semua tag
+	Alergen    *[]string `json:"alergen,omitempty"`     // menggantikan semua alergen
+}
+
+var errKategoriNotFound = errors.New("kategori not found in this stan")
+
+// menuMetaUpdate hasil validasi kategori / tag / alergen dari payload
+type menuMetaUpdate struct {
+	Changed     bool
+	ReplaceTags bool
+	Tags        []app.Tag
+}
+
+// applyMenuMeta menerapkan kategori & alergen ke menu dan menyiapkan tag.
+// Tag baru dibuat di tabel tags; relasi menu_tags diganti oleh pemanggil
+// (lihat replaceMenuTags) setelah menu tersimpan.
+func applyMenuMeta(stanID uint, menu *app.Menu, kategoriID *string, tags, alergen *[]string) (menuMetaUpdate, error) {
+	var u menuMetaUpdate
+
+	if kategoriID != nil {
+		id, err := resolveKategori(stanID, *kategoriID)
+		if err != nil {
+			return u, err
+		}
+		menu.KategoriID = id
+		menu.Kategori = nil
+		u.Changed = true
+	}
+	if alergen != nil {
+		a, err := app.ParseAlergen(*alergen)
+		if err != nil {
+			return u, err
+		}
+		menu.Alergen = a
+		u.Changed = true
+	}
+	if tags != nil {
+		names, err := app.NormalizeTags(*tags)
+		if err != nil {
+			return u, err
+		}
+		t, err := app.FindOrCreateTags(app.DB, names)
+		if err != nil {
+			return u, err
+		}
+		u.Tags = t
+		u.ReplaceTags = true
+		u.Changed = true
+	}
+	return u, nil
+}
+
+// replaceMenuTags ganti semua tag menu (jika payload mengirim tags)
+func replaceMenuTags(menu *app.Menu, u menuMetaUpdate) error {
+	if !u.ReplaceTags {
+		return nil
+	}
+	return app.DB.Model(menu).Association("Tags").Replace(u.Tags)
+}
+
+// menuMetaJSON kategori, tag & alergen untuk response admin (Kategori & Tags di-preload)
+func menuMetaJSON(m app.Menu) gin.H {
+	var kategori interface{}
+	if m.Kategori != nil {
+		kategori = gin.H{"id": m.Kategori.PublicID, "nama": m.Kategori.Nama}
+	}
+	return gin.H{
+		"kategori": kategori,
+		"tags":     m.TagNames(),
+		"alergen":  m.AlergenList(),
+	}
+}
+
+// applyStockPayload menerapkan field stok / is_available dari payload update.
+// Return true jika ada field yang berubah.
+func applyStockPayload(menu *app.Menu, p updateMenuPayload) bool {
+	changed := false
+	if p.Stok != nil {
+		v := *p.Stok
+		menu.Stok = &v
+		changed = true
+	}
+	if p.StokUnlimited != nil && *p.StokUnlimited {
+		menu.Stok = nil
+		changed = true
+	}
+	if p.IsAvailable != nil {
+		menu.IsAvailable = *p.IsAvailable
+		changed = true
+	}
+	return changed
+}
+
+// menuStockJSON field stok untuk response admin.
+func menuStockJSON(m app.Menu) gin.H {
+	return gin.H{
+		"stok":           m.Stok,
+		"stok_unlimited": m.Stok == nil,
+		"is_available":   m.IsAvailable,
+		"sold_out":       m.SoldOut(),
+	}
 }
 
 //
@@ -52,19 +168,37 @@ func AdminCreateMenu(c *gin.Context) {
 		Harga:       p.Harga,
 		Jenis:       app.MenuJenis(p.Jenis),
 		Deskripsi:   p.Deskripsi,
+		Stok:        p.Stok,
+		IsAvailable: true,
 	}
 
+	meta, err := applyMenuMeta(stan.ID, &menu, &p.KategoriID, &p.Tags, &p.Alergen)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	menu.Tags = meta.Tags
+
 	if err := app.DB.Create(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create menu"})
 		return
 	}
 
+	// is_available=false harus di-update terpisah (default:true di kolom)
+	if p.IsAvailable != nil && !*p.IsAvailable {
+		menu.IsAvailable = false
+		app.DB.Model(&menu).UpdateColumn("is_available", false)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message":      "menu created",
 		"menu_id":      menu.PublicID,
 		"nama_makanan": menu.NamaMakanan,
 		"harga":        menu.Harga,
 		"jenis":        menu.Jenis,
+		"stok":         menuStockJSON(menu),
+		"tags":         menu.TagNames(),
+		"alergen":      menu.AlergenList(),
 	})
 }
 
@@ -82,6 +216,8 @@ func AdminListMenus(c *gin.Context) {
 
 	var menus []app.Menu
 	if err := app.DB.
+		Preload("Kategori").
+		Preload("Tags").
 		Where("stan_id = ?", stan.ID).
 		Order("created_at DESC").
 		Find(&menus).Error; err != nil {
@@ -93,11 +229,15 @@ func AdminListMenus(c *gin.Context) {
 	out := make([]gin.H, 0, len(menus))
 	for _, m := range menus {
 		out = append(out, gin.H{
-			"menu_id":      m.PublicID,
-			"nama_makanan": m.NamaMakanan,
-			"harga":        m.Harga,
-			"jenis":        m.Jenis,
-			"deskripsi":    m.Deskripsi,
+			"menu_id":       m.PublicID,
+			"nama_makanan":  m.NamaMakanan,
+			"harga":         m.Harga,
+			"jenis":         m.Jenis,
+			"deskripsi":     m.Deskripsi,
+			"stok":          menuStockJSON(m),
+			"image_url":     nullIfEmpty(m.ImageURL()),
+			"thumbnail_url": nullIfEmpty(m.ThumbURL()),
+			"meta":          menuMetaJSON(m),
 		})
 	}
 
@@ -120,6 +260,8 @@ func AdminGetMenu(c *gin.Context) {
 
 	var menu app.Menu
 	if err := app.DB.
+		Preload("Kategori").
+		Preload("Tags").
 		Where("public_id = ? AND stan_id = ?", pub, stan.ID).
 		First(&menu).Error; err != nil {
 
@@ -128,11 +270,15 @@ func AdminGetMenu(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"menu_id":      menu.PublicID,
-		"nama_makanan": menu.NamaMakanan,
-		"harga":        menu.Harga,
-		"jenis":        menu.Jenis,
-		"deskripsi":    menu.Deskripsi,
+		"menu_id":       menu.PublicID,
+		"nama_makanan":  menu.NamaMakanan,
+		"harga":         menu.Harga,
+		"jenis":         menu.Jenis,
+		"deskripsi":     menu.Deskripsi,
+		"stok":          menuStockJSON(menu),
+		"image_url":     nullIfEmpty(menu.ImageURL()),
+		"thumbnail_url": nullIfEmpty(menu.ThumbURL()),
+		"meta":          menuMetaJSON(menu),
 	})
 }
 
@@ -177,11 +323,22 @@ func AdminUpdateMenu(c *gin.Context) {
 	if p.Deskripsi != nil {
 		menu.Deskripsi = *p.Deskripsi
 	}
+	applyStockPayload(&menu, p)
+
+	meta, err := applyMenuMeta(stan.ID, &menu, p.KategoriID, p.Tags, p.Alergen)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	if err := app.DB.Save(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update menu"})
 		return
 	}
+	if err := replaceMenuTags(&menu, meta); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update menu tags"})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "menu updated",
@@ -212,10 +369,26 @@ func AdminDeleteMenu(c *gin.Context) {
 		return
 	}
 
+	// lepas dari cakupan diskon (tabel join diskon_menus)
+	if err := app.DB.Exec("DELETE FROM diskon_menus WHERE menu_id = ?", menu.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete menu"})
+		return
+	}
+	if err := app.DB.Model(&menu).Association("Tags").Clear(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete menu"})
+		return
+	}
+	// keluarkan dari keranjang siswa
+	if err := app.DB.Where("menu_id = ?", menu.ID).Delete(&app.CartItem{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete menu"})
+		return
+	}
+
 	if err := app.DB.Delete(&menu).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete menu"})
 		return
 	}
+	app.DeleteMenuImageFiles(c.Request.Context(), menu.ImageKey, menu.ThumbKey)
 
 	c.JSON(http.StatusOK, gin.H{"message": "menu deleted"})
 }
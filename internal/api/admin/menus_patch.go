@@ -48,6 +48,18 @@ func AdminPatchMenu(c *gin.Context) {
 		menu.Deskripsi = *p.Deskripsi
 		changed = true
 	}
+	if applyStockPayload(&menu, p) {
+		changed = true
+	}
+
+	meta, err := applyMenuMeta(stan.ID, &menu, p.KategoriID, p.Tags, p.Alergen)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if meta.Changed {
+		changed = true
+	}
 
 	if !changed {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -62,6 +74,12 @@ func AdminPatchMenu(c *gin.Context) {
 		})
 		return
 	}
+	if err := replaceMenuTags(&menu, meta); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": "failed to update menu tags",
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "menu updated",
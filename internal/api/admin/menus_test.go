@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// gambar menu di response admin sama dengan endpoint siswa (top-level)
+func TestAdminMenuImageFields(t *testing.T) {
+	db := apptest.OpenDB(t)
+	admin, stan := seedAdminStan(t, db)
+
+	prev := app.Files
+	app.Files = &app.LocalStorage{Dir: t.TempDir(), BaseURL: "/uploads"}
+	t.Cleanup(func() { app.Files = prev })
+
+	withImage := apptest.SeedMenu(t, db, stan.ID, "Nasi Goreng", 10000)
+	db.Model(withImage).Updates(map[string]interface{}{
+		"image_key": "menus/a/full.jpg",
+		"thumb_key": "menus/a/thumb.jpg",
+	})
+	noImage := apptest.SeedMenu(t, db, stan.ID, "Es Teh", 5000)
+
+	cases := []struct {
+		menu  *app.Menu
+		image interface{}
+		thumb interface{}
+	}{
+		{withImage, "/uploads/menus/a/full.jpg", "/uploads/menus/a/thumb.jpg"},
+		{noImage, nil, nil},
+	}
+	for _, tc := range cases {
+		w := callHandler(t, AdminGetMenu, admin.ID, http.MethodGet, "/", nil, "id", tc.menu.PublicID)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+		}
+		body := decodeBody(t, w)
+		if _, nested := body["image"]; nested {
+			t.Errorf("%s: masih ada field image bersarang", tc.menu.NamaMakanan)
+		}
+		if body["image_url"] != tc.image || body["thumbnail_url"] != tc.thumb {
+			t.Errorf("%s: image_url/thumbnail_url = %v/%v, want %v/%v",
+				tc.menu.NamaMakanan, body["image_url"], body["thumbnail_url"], tc.image, tc.thumb)
+		}
+	}
+}
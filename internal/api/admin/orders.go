@@ -1,7 +1,11 @@
 package admin
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -26,6 +30,10 @@ func adminStatusLabel(s app.TransaksiStatus) string {
 		return "Sedang diantar"
 	case app.StatusSampai:
 		return "Pesanan sudah sampai"
+	case app.StatusDibatalkan:
+		return "Dibatalkan siswa"
+	case app.StatusDitolak:
+		return "Ditolak stan"
 	default:
 		return "Status tidak diketahui"
 	}
@@ -36,8 +44,76 @@ func adminStatusLabel(s app.TransaksiStatus) string {
 // LIST ORDERS (ADMIN STAN)
 // =========================
 // GET /api/admin/orders
+// optional:
+//   ?status=belum dikonfirm,dimasak  (boleh berulang)
+//   ?from=YYYY-MM-DD&to=YYYY-MM-DD    (tanggal WIB, inklusif)
+//   ?q=<nama siswa>
+//   ?sort=newest|oldest|updated       (default newest)
+//   ?page=1&limit=20
+//   ?pickup_slot=<slot_id>|none       (pre-order per slot / tanpa slot)
+//   ?group_by=pickup_slot             (batch dapur: tanpa pagination,
+//                                      default hari ini jika from/to kosong)
 //
 
+// adminOrderJSON 1 order untuk list admin (Details.Menu harus di-preload)
+func adminOrderJSON(t app.Transaksi, namaSiswa string) gin.H {
+	items := make([]gin.H, 0, len(t.Details))
+	var total float64
+
+	for _, d := range t.Details {
+		sub := d.Subtotal()
+		total += sub
+
+		items = append(items, gin.H{
+			"menu_id":       d.Menu.PublicID,
+			"nama_makanan":  d.MenuName(),
+			"qty":           d.Qty,
+			"harga_normal":  app.Round2(d.OriginalPrice()),
+			"harga_beli":    app.Round2(d.HargaBeli),
+			"diskon_nama":   d.DiskonNama,
+			"diskon_persen": d.DiskonPersen,
+			"potongan":      app.Round2(d.DiskonAmount()),
+			"subtotal":      app.Round2(sub),
+		})
+	}
+
+	return gin.H{
+		"transaksi_id": t.PublicID,
+		"nama_siswa":   namaSiswa,
+
+		// STATUS
+		"status":       t.Status,
+		"status_label": adminStatusLabel(t.Status),
+
+		// WAKTU (UX)
+		"created_at":       t.CreatedAt,
+		"created_at_human": app.FormatTimeWithClock(t.CreatedAt),
+		"updated_at_human": app.FormatTimeWithClock(t.UpdatedAt),
+
+		// PENGAMBILAN (pre-order)
+		"pickup": app.PickupJSON(t),
+
+		// PEMBAYARAN
+		"payment_method": t.PaymentMethod,
+		"payment_status": t.PaymentStatus,
+		"cancel_reason":  t.CancelReason,
+
+		// DATA
+		"total":            app.Round2(t.Total()),
+		"subtotal":         app.Round2(total),
+		"voucher_kode":     t.VoucherKode,
+		"voucher_potongan": app.Round2(t.VoucherPotongan),
+		"items":            items,
+	}
+}
+
+// adminOrderSorts: nilai ?sort= yang diizinkan → ORDER BY
+var adminOrderSorts = map[string]string{
+	"newest":  "transaksis.created_at DESC, transaksis.id DESC",
+	"oldest":  "transaksis.created_at ASC, transaksis.id ASC", // antrian dapur
+	"updated": "transaksis.updated_at DESC, transaksis.id DESC",
+}
+
 func AdminOrders(c *gin.Context) {
 	uidv, ok := c.Get("user_id")
 	if !ok {
@@ -53,56 +129,265 @@ func AdminOrders(c *gin.Context) {
 		return
 	}
 
+	// =========================
+	// FILTER (tanpa status)
+	// =========================
+	base := app.DB.Model(&app.Transaksi{}).Where("transaksis.stan_id = ?", stan.ID)
+
+	groupBy := c.Query("group_by")
+	if groupBy != "" && groupBy != "pickup_slot" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid group_by, use pickup_slot"})
+		return
+	}
+
+	fromQ, toQ := c.Query("from"), c.Query("to")
+	if groupBy != "" && fromQ == "" && toQ == "" {
+		fromQ = time.Now().In(app.JakartaLoc()).Format("2006-01-02")
+		toQ = fromQ
+	}
+	from, to, err := app.ParseDateRangeJakarta(fromQ, toQ)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if from != nil {
+		base = base.Where("transaksis.created_at >= ?", *from)
+	}
+	if to != nil {
+		base = base.Where("transaksis.created_at < ?", *to)
+	}
+
+	if search := strings.TrimSpace(c.Query("q")); search != "" {
+		base = base.
+			Joins("JOIN siswas ON siswas.id = transaksis.siswa_id").
+			Where("siswas.nama LIKE ? ESCAPE '!'", likeContains(search))
+	}
+
+	switch slotPub := c.Query("pickup_slot"); slotPub {
+	case "":
+	case "none":
+		base = base.Where("transaksis.pickup_slot_id IS NULL")
+	default:
+		var slot app.PickupSlot
+		if err := app.DB.Where("public_id = ?", slotPub).First(&slot).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "pickup slot not found"})
+			return
+		}
+		base = base.Where("transaksis.pickup_slot_id = ?", slot.ID)
+	}
+
+	statuses, err := app.ParseStatusFilter(c.QueryArray("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	orderBy, ok := adminOrderSorts[c.DefaultQuery("sort", "newest")]
+	if !ok {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, use newest|oldest|updated"})
+		return
+	}
+
+	// =========================
+	// BADGE: jumlah per status (abaikan filter status)
+	// =========================
+	type statusCount struct {
+		Status app.TransaksiStatus
+		Total  int64
+	}
+	var counts []statusCount
+	if err := base.Session(&gorm.Session{}).
+		Select("transaksis.status AS status, COUNT(*) AS total").
+		Group("transaksis.status").
+		Scan(&counts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count orders"})
+		return
+	}
+	statusCounts := gin.H{}
+	for _, st := range app.AllStatuses {
+		statusCounts[string(st)] = int64(0)
+	}
+	for _, sc := range counts {
+		statusCounts[string(sc.Status)] = sc.Total
+	}
+
+	// =========================
+	// COUNT + PAGE
+	// =========================
+	q := base.Session(&gorm.Session{})
+	if len(statuses) > 0 {
+		q = q.Where("transaksis.status IN ?", statuses)
+	}
+
+	if groupBy == "pickup_slot" {
+		adminOrdersByPickupSlot(c, stan, q, statusCounts)
+		return
+	}
+
+	pg := app.ParsePagination(c)
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count orders"})
+		return
+	}
+
 	var trxs []app.Transaksi
-	if err := app.DB.
-		Where("stan_id = ?", stan.ID).
+	if err := q.Session(&gorm.Session{}).
 		Preload("Details.Menu").
-		Order("created_at DESC").
+		Order(orderBy).
+		Offset(pg.Offset()).
+		Limit(pg.Limit).
 		Find(&trxs).Error; err != nil {
 
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch orders"})
 		return
 	}
 
+	// nama siswa (1 query untuk 1 halaman)
+	siswaIDs := make([]uint, 0, len(trxs))
+	for _, t := range trxs {
+		siswaIDs = append(siswaIDs, t.SiswaID)
+	}
+	siswaNames := siswaNamesByID(siswaIDs)
+
 	out := make([]gin.H, 0, len(trxs))
 	for _, t := range trxs {
-		items := make([]gin.H, 0, len(t.Details))
-		var total float64
+		out = append(out, adminOrderJSON(t, siswaNames[t.SiswaID]))
+	}
 
-		for _, d := range t.Details {
-			sub := float64(d.Qty) * d.HargaBeli
-			total += sub
-
-			items = append(items, gin.H{
-				"menu_id":      d.Menu.PublicID,
-				"nama_makanan": d.Menu.NamaMakanan,
-				"qty":          d.Qty,
-				"harga_beli":   app.Round2(d.HargaBeli),
-				"subtotal":     app.Round2(sub),
-			})
+	c.JSON(http.StatusOK, gin.H{
+		"stan_id":       stan.PublicID,
+		"orders":        out,
+		"pagination":    pg.Meta(total),
+		"status_counts": statusCounts,
+	})
+}
+
+// adminOrdersByPickupSlot ?group_by=pickup_slot: order dikelompokkan per slot
+// (urut jam ambil, order tanpa slot paling akhir) + rekap menu yang harus
+// disiapkan per slot, supaya dapur bisa masak per batch sebelum bel istirahat.
+func adminOrdersByPickupSlot(c *gin.Context, stan app.Stan, q *gorm.DB, statusCounts gin.H) {
+	var trxs []app.Transaksi
+	if err := q.Session(&gorm.Session{}).
+		Preload("Details.Menu").
+		Order("transaksis.pickup_at IS NULL, transaksis.pickup_at ASC, transaksis.created_at ASC, transaksis.id ASC").
+		Find(&trxs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch orders"})
+		return
+	}
+
+	siswaIDs := make([]uint, 0, len(trxs))
+	slotIDs := []uint{}
+	for _, t := range trxs {
+		siswaIDs = append(siswaIDs, t.SiswaID)
+		if t.PickupSlotID != nil {
+			slotIDs = append(slotIDs, *t.PickupSlotID)
 		}
+	}
+	siswaNames := siswaNamesByID(siswaIDs)
+
+	slots := map[uint]app.PickupSlot{}
+	if len(slotIDs) > 0 {
+		var list []app.PickupSlot
+		app.DB.Where("id IN ?", slotIDs).Find(&list)
+		for _, s := range list {
+			slots[s.ID] = s
+		}
+	}
 
-		out = append(out, gin.H{
-			"transaksi_id": t.PublicID,
+	type prepItem struct {
+		MenuID string
+		Nama   string
+		Qty    int
+	}
+	type group struct {
+		first  app.Transaksi
+		orders []gin.H
+		active int
+		prep   []*prepItem
+		byMenu map[string]*prepItem
+	}
+
+	// key = pickup_slot_id@pickup_at ("none" = tanpa slot)
+	groups := []*group{}
+	byKey := map[string]*group{}
+	for _, t := range trxs {
+		key := "none"
+		if t.PickupSlotID != nil && t.PickupAt != nil {
+			key = fmt.Sprintf("%d@%d", *t.PickupSlotID, t.PickupAt.Unix())
+		}
+		g, ok := byKey[key]
+		if !ok {
+			g = &group{first: t, byMenu: map[string]*prepItem{}}
+			byKey[key] = g
+			groups = append(groups, g)
+		}
+		g.orders = append(g.orders, adminOrderJSON(t, siswaNames[t.SiswaID]))
 
-			// STATUS
-			"status":       t.Status,
-			"status_label": adminStatusLabel(t.Status),
+		// order batal / ditolak tidak perlu dimasak
+		if t.Status.IsCancelled() {
+			continue
+		}
+		g.active++
+		for _, d := range t.Details {
+			it, ok := g.byMenu[d.Menu.PublicID]
+			if !ok {
+				it = &prepItem{MenuID: d.Menu.PublicID, Nama: d.MenuName()}
+				g.byMenu[d.Menu.PublicID] = it
+				g.prep = append(g.prep, it)
+			}
+			it.Qty += d.Qty
+		}
+	}
 
-			// WAKTU (UX)
-			"created_at":        t.CreatedAt,
-			"created_at_human": app.FormatTimeWithClock(t.CreatedAt),
-			"updated_at_human": app.FormatTimeWithClock(t.UpdatedAt),
+	out := make([]gin.H, 0, len(groups))
+	for _, g := range groups {
+		var slot interface{} = nil
+		if t := g.first; t.PickupSlotID != nil && t.PickupAt != nil {
+			sj := gin.H{
+				"nama":            t.PickupSlotNama,
+				"pickup_at":       t.PickupAt,
+				"pickup_at_human": app.FormatTimeWithClock(*t.PickupAt),
+			}
+			if s, ok := slots[*t.PickupSlotID]; ok {
+				sj["slot_id"] = s.PublicID
+				sj["jam_mulai"] = s.JamMulai
+				sj["jam_selesai"] = s.JamSelesai
+			}
+			slot = sj
+		}
 
-			// DATA
-			"total": app.Round2(total),
-			"items": items,
+		sort.SliceStable(g.prep, func(i, j int) bool {
+			if g.prep[i].Qty != g.prep[j].Qty {
+				return g.prep[i].Qty > g.prep[j].Qty
+			}
+			return g.prep[i].Nama < g.prep[j].Nama
+		})
+		prep := make([]gin.H, 0, len(g.prep))
+		for _, it := range g.prep {
+			prep = append(prep, gin.H{
+				"menu_id":      it.MenuID,
+				"nama_makanan": it.Nama,
+				"qty":          it.Qty,
+			})
+		}
+
+		out = append(out, gin.H{
+			"pickup_slot":   slot, // null = tanpa slot (ambil langsung)
+			"order_count":   len(g.orders),
+			"active_orders": g.active,
+			"prep":          prep,
+			"orders":        g.orders,
 		})
 	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"stan_id": stan.PublicID,
-		"orders":  out,
+		"stan_id":       stan.PublicID,
+		"group_by":      "pickup_slot",
+		"groups":        out,
+		"total":         len(trxs),
+		"status_counts": statusCounts,
 	})
 }
 
@@ -136,6 +421,7 @@ func AdminUpdateOrderStatus(c *gin.Context) {
 
 	var payload struct {
 		Status app.TransaksiStatus `json:"status" binding:"required"`
+		Reason string              `json:"reason,omitempty"` // wajib untuk "ditolak"
 	}
 	if err := c.ShouldBindJSON(&payload); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -166,8 +452,8 @@ func AdminUpdateOrderStatus(c *gin.Context) {
 
 	// valid transitions
 	allowed := map[app.TransaksiStatus][]app.TransaksiStatus{
-		app.StatusBelumDikonfirm: {app.StatusDimasak},
-		app.StatusDimasak:        {app.StatusDiantar},
+		app.StatusBelumDikonfirm: {app.StatusDimasak, app.StatusDitolak},
+		app.StatusDimasak:        {app.StatusDiantar, app.StatusDitolak},
 		app.StatusDiantar:        {app.StatusSampai},
 	}
 
@@ -192,25 +478,94 @@ func AdminUpdateOrderStatus(c *gin.Context) {
 		return
 	}
 
-	res := app.DB.Model(&app.Transaksi{}).
-		Where("id = ? AND status = ?", trx.ID, trx.Status).
-		Updates(map[string]interface{}{
-			"status":     target,
-			"updated_at": time.Now(),
-		})
+	// tolak pesanan: alasan wajib + refund saldo (jika bayar wallet)
+	if target == app.StatusDitolak {
+		rejectOrder(c, &trx, strings.TrimSpace(payload.Reason))
+		return
+	}
 
-	if res.Error != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
+	// sampai: order tunai sekaligus lunas
+	if target == app.StatusSampai {
+		if err := app.CompleteTransaksi(app.DB, &trx, []app.TransaksiStatus{cur}); err != nil {
+			if errors.Is(err, app.ErrStatusChanged) {
+				c.JSON(http.StatusConflict, gin.H{"error": "status already changed"})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
+			return
+		}
+	} else {
+		res := app.DB.Model(&app.Transaksi{}).
+			Where("id = ? AND status = ?", trx.ID, trx.Status).
+			Updates(map[string]interface{}{
+				"status":     target,
+				"updated_at": time.Now(),
+			})
+
+		if res.Error != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
+			return
+		}
+		if res.RowsAffected == 0 {
+			c.JSON(http.StatusConflict, gin.H{"error": "status already changed"})
+			return
+		}
+		trx.Status = target
+	}
+
+	app.PublishOrderEvent(&trx, app.EventOrderStatus)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "status updated",
+		"transaksi_id":   trxPub,
+		"new_status":     target,
+		"payment_status": trx.PaymentStatus,
+	})
+}
+
+// rejectOrder: admin stan menolak order (mis. menu habis).
+func rejectOrder(c *gin.Context, trx *app.Transaksi, reason string) {
+	if reason == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "reason is required to reject an order"})
+		return
+	}
+
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	refund, err := app.CancelTransaksi(
+		tx, trx,
+		[]app.TransaksiStatus{trx.Status},
+		app.StatusDitolak,
+		reason,
+	)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, app.ErrStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "status already changed"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reject order"})
 		return
 	}
-	if res.RowsAffected == 0 {
-		c.JSON(http.StatusConflict, gin.H{"error": "status already changed"})
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
 		return
 	}
 
+	app.PublishOrderEvent(trx, app.EventOrderCancelled)
+
 	c.JSON(http.StatusOK, gin.H{
-		"message":      "status updated",
-		"transaksi_id": trxPub,
-		"new_status":   target,
+		"message":        "order rejected",
+		"transaksi_id":   trx.PublicID,
+		"new_status":     trx.Status,
+		"reason":         trx.CancelReason,
+		"payment_status": trx.PaymentStatus,
+		"refund":         refund,
 	})
 }
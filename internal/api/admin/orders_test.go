@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// Order tunai lunas saat diserahkan: lewat update status & scan QR pickup
+func TestCashOrderPaidOnSampai(t *testing.T) {
+	db := apptest.OpenDB(t)
+	admin, stan := seedAdminStan(t, db)
+	_, siswa := apptest.SeedSiswa(t, db, 0)
+
+	newOrder := func(method app.PaymentMethod, pay app.PaymentStatus) *app.Transaksi {
+		trx := app.Transaksi{
+			StanID:        stan.ID,
+			SiswaID:       siswa.ID,
+			Status:        app.StatusDiantar,
+			PaymentMethod: method,
+			PaymentStatus: pay,
+		}
+		if err := db.Create(&trx).Error; err != nil {
+			t.Fatal(err)
+		}
+		return &trx
+	}
+
+	cases := []struct {
+		name   string
+		trx    *app.Transaksi
+		viaQR  bool
+		payAft app.PaymentStatus
+	}{
+		{"cash via status", newOrder(app.PaymentCash, app.PaymentPending), false, app.PaymentPaid},
+		{"cash via qr", newOrder(app.PaymentCash, app.PaymentPending), true, app.PaymentPaid},
+		{"wallet tetap paid", newOrder(app.PaymentWallet, app.PaymentPaid), false, app.PaymentPaid},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var w *httptest.ResponseRecorder
+			if tc.viaQR {
+				w = callHandler(t, AdminVerifyOrderQR, admin.ID, http.MethodPost, "/", map[string]interface{}{
+					"payload":     app.OrderQRPayload(tc.trx.PublicID, stan.PublicID),
+					"mark_sampai": true,
+				})
+			} else {
+				w = callHandler(t, AdminUpdateOrderStatus, admin.ID, http.MethodPatch, "/",
+					map[string]string{"status": string(app.StatusSampai)}, "id", tc.trx.PublicID)
+			}
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+			if got := decodeBody(t, w)["payment_status"]; got != string(tc.payAft) {
+				t.Errorf("response payment_status = %v, want %s", got, tc.payAft)
+			}
+
+			var got app.Transaksi
+			db.First(&got, tc.trx.ID)
+			if got.Status != app.StatusSampai || got.PaymentStatus != tc.payAft {
+				t.Errorf("db = %s/%s, want sampai/%s", got.Status, got.PaymentStatus, tc.payAft)
+			}
+		})
+	}
+}
+
+// ?q= dicocokkan literal: % dan _ dari user bukan wildcard LIKE
+func TestAdminOrdersSearchEscapesWildcards(t *testing.T) {
+	db := apptest.OpenDB(t)
+	admin, stan := seedAdminStan(t, db)
+
+	for _, nama := range []string{"Budi_1", "Budi21", "Rina 100%", "Rina 1000"} {
+		_, siswa := apptest.SeedSiswa(t, db, 0)
+		if err := db.Model(siswa).Update("nama", nama).Error; err != nil {
+			t.Fatal(err)
+		}
+		trx := app.Transaksi{StanID: stan.ID, SiswaID: siswa.ID, Status: app.StatusDimasak}
+		if err := db.Create(&trx).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cases := []struct {
+		q    string
+		want int
+	}{
+		{"i_1", 1},
+		{"100%", 1},
+		{"%", 1},
+		{"_", 1},
+		{"Budi", 2},
+	}
+	for _, tc := range cases {
+		w := callHandler(t, AdminOrders, admin.ID, http.MethodGet, "/?q="+url.QueryEscape(tc.q), nil)
+		if w.Code != http.StatusOK {
+			t.Fatalf("q=%q: status = %d, body %s", tc.q, w.Code, w.Body.String())
+		}
+		if got := len(decodeBody(t, w)["orders"].([]interface{})); got != tc.want {
+			t.Errorf("q=%q: %d orders, want %d", tc.q, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package admin
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// PDF REPORT HELPERS
+// =========================
+//
+// Laporan A4 portrait untuk pemilik stan (rekap & bulanan).
+// Header stan diulang tiap halaman, tabel transaksi lanjut ke halaman
+// berikutnya dengan header kolom diulang, footer berisi nomor halaman.
+
+const (
+	pdfMarginX   = 15.0
+	pdfMarginTop = 15.0
+	pdfMarginBot = 18.0
+	pdfRowH      = 7.0
+)
+
+// pdfColumn 1 kolom tabel
+type pdfColumn struct {
+	Title string
+	Width float64
+	Align string // "", "C", "R"
+}
+
+// reportPDF pembungkus fpdf + translator UTF-8 (nama siswa / menu)
+type reportPDF struct {
+	*fpdf.Fpdf
+	tr func(string) string
+}
+
+// newReportPDF membuat dokumen dengan header stan + footer halaman
+func newReportPDF(title string, stan app.Stan, periode string) *reportPDF {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(pdfMarginX, pdfMarginTop, pdfMarginX)
+	pdf.SetAutoPageBreak(true, pdfMarginBot)
+	pdf.AliasNbPages("{nb}")
+
+	r := &reportPDF{Fpdf: pdf, tr: pdf.UnicodeTranslatorFromDescriptor("")}
+	dibuat := tanggalCetak(time.Now())
+
+	pdf.SetHeaderFunc(func() {
+		pdf.SetFont("Arial", "B", 14)
+		pdf.CellFormat(0, 8, r.tr(title), "", 1, "", false, 0, "")
+
+		pdf.SetFont("Arial", "", 10)
+		pdf.CellFormat(0, 5, r.tr("Stan      : "+stan.NamaStan), "", 1, "", false, 0, "")
+		pdf.CellFormat(0, 5, r.tr("Pemilik   : "+stan.NamaPemilik), "", 1, "", false, 0, "")
+		pdf.CellFormat(0, 5, r.tr("Periode   : "+periode), "", 1, "", false, 0, "")
+
+		y := pdf.GetY() + 2
+		pageW, _ := pdf.GetPageSize()
+		pdf.Line(pdfMarginX, y, pageW-pdfMarginX, y)
+		pdf.SetY(y + 4)
+	})
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-12)
+		pdf.SetFont("Arial", "I", 8)
+		pdf.CellFormat(0, 5, "Dicetak "+dibuat, "", 0, "", false, 0, "")
+		pdf.CellFormat(0, 5, fmt.Sprintf("Halaman %d/{nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+	})
+
+	pdf.AddPage()
+	return r
+}
+
+// section judul bagian (pindah halaman jika sisa ruang tidak cukup)
+func (r *reportPDF) section(title string) {
+	r.ensureSpace(pdfRowH * 3)
+	r.Ln(3)
+	r.SetFont("Arial", "B", 11)
+	r.CellFormat(0, 7, r.tr(title), "", 1, "", false, 0, "")
+}
+
+// ensureSpace AddPage jika tinggi h tidak muat di halaman ini
+func (r *reportPDF) ensureSpace(h float64) bool {
+	_, pageH := r.GetPageSize()
+	if r.GetY()+h > pageH-pdfMarginBot {
+		r.AddPage()
+		return true
+	}
+	return false
+}
+
+func (r *reportPDF) tableHeader(cols []pdfColumn) {
+	r.SetFont("Arial", "B", 9)
+	r.SetFillColor(230, 230, 230)
+	for _, col := range cols {
+		r.CellFormat(col.Width, pdfRowH, col.Title, "1", 0, "C", true, 0, "")
+	}
+	r.Ln(-1)
+}
+
+// table menulis tabel; header kolom diulang di setiap halaman baru
+func (r *reportPDF) table(cols []pdfColumn, rows [][]string) {
+	r.ensureSpace(pdfRowH * 2)
+	r.tableHeader(cols)
+
+	r.SetFont("Arial", "", 9)
+	for _, row := range rows {
+		if r.ensureSpace(pdfRowH) {
+			r.tableHeader(cols)
+			r.SetFont("Arial", "", 9)
+		}
+		for i, col := range cols {
+			r.CellFormat(col.Width, pdfRowH, r.fit(row[i], col.Width), "1", 0, col.Align, false, 0, "")
+		}
+		r.Ln(-1)
+	}
+}
+
+// totalRow baris total tebal (label di kiri, nilai di kolom terakhir)
+func (r *reportPDF) totalRow(cols []pdfColumn, label, value string) {
+	r.ensureSpace(pdfRowH)
+
+	var labelW float64
+	for _, col := range cols[:len(cols)-1] {
+		labelW += col.Width
+	}
+	r.SetFont("Arial", "B", 9)
+	r.CellFormat(labelW, pdfRowH, label, "1", 0, "R", false, 0, "")
+	r.CellFormat(cols[len(cols)-1].Width, pdfRowH, value, "1", 1, "R", false, 0, "")
+}
+
+// voucherRow baris potongan voucher sebelum TOTAL tabel per menu
+// (pendapatan per menu dihitung sebelum voucher). Tidak ditulis jika 0.
+func (r *reportPDF) voucherRow(cols []pdfColumn, voucher float64) {
+	if app.Round2(voucher) == 0 {
+		return
+	}
+	r.totalRow(cols, "POTONGAN VOUCHER", app.FormatRupiah(-voucher))
+}
+
+// keyValues daftar "label : nilai" (ringkasan)
+func (r *reportPDF) keyValues(pairs [][2]string) {
+	r.SetFont("Arial", "", 10)
+	for _, p := range pairs {
+		r.ensureSpace(6)
+		r.CellFormat(55, 6, r.tr(p[0]), "", 0, "", false, 0, "")
+		r.CellFormat(0, 6, r.tr(": "+p[1]), "", 1, "", false, 0, "")
+	}
+}
+
+// signature blok tanda tangan pemilik stan (untuk arsip sekolah)
+func (r *reportPDF) signature(stan app.Stan) {
+	r.ensureSpace(45)
+	r.Ln(10)
+
+	pageW, _ := r.GetPageSize()
+	x := pageW - pdfMarginX - 70
+
+	r.SetFont("Arial", "", 10)
+	r.SetX(x)
+	r.CellFormat(70, 5, r.tr("Pemilik Stan,"), "", 1, "C", false, 0, "")
+	r.Ln(22)
+	r.SetX(x)
+	r.CellFormat(70, 5, r.tr("( "+stan.NamaPemilik+" )"), "T", 1, "C", false, 0, "")
+}
+
+// fit memotong teks yang lebih lebar dari kolom
+func (r *reportPDF) fit(s string, width float64) string {
+	s = r.tr(s)
+	max := width - 2
+	if r.GetStringWidth(s) <= max {
+		return s
+	}
+	for len(s) > 0 && r.GetStringWidth(s+"...") > max {
+		s = s[:len(s)-1]
+	}
+	return s + "..."
+}
+
+func sendPDF(c *gin.Context, r *reportPDF, filename string) {
+	var buf bytes.Buffer
+	if err := r.Output(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate pdf"})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="`+filename+`"`)
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+func tanggalCetak(t time.Time) string {
+	return tanggalWIB(t) + " WIB"
+}
+
+//
+// =========================
+// TABLE BUILDERS
+// =========================
+//
+
+var pdfTrxColumns = []pdfColumn{
+	{"No", 10, "C"},
+	{"Tanggal (WIB)", 30, "C"},
+	{"Transaksi ID", 50, ""},
+	{"Nama Siswa", 40, ""},
+	{"Item", 15, "C"},
+	{"Total", 35, "R"},
+}
+
+func pdfTrxRows(trxs []app.Transaksi, names map[uint]string) [][]string {
+	rows := make([][]string, 0, len(trxs))
+	for i, t := range trxs {
+		items := 0
+		for _, d := range t.Details {
+			items += d.Qty
+		}
+		rows = append(rows, []string{
+			strconv.Itoa(i + 1),
+			tanggalWIB(t.CreatedAt),
+			t.PublicID,
+			names[t.SiswaID],
+			strconv.Itoa(items),
+			app.FormatRupiah(trxTotal(t)),
+		})
+	}
+	return rows
+}
+
+var pdfMenuColumns = []pdfColumn{
+	{"Menu", 80, ""},
+	{"Qty", 20, "C"},
+	{"Diskon", 40, "R"},
+	{"Pendapatan", 40, "R"},
+}
+
+func pdfMenuRows(menus []reportMenu) [][]string {
+	rows := make([][]string, 0, len(menus))
+	for _, m := range menus {
+		rows = append(rows, []string{
+			m.Nama,
+			strconv.Itoa(m.Qty),
+			app.FormatRupiah(m.Discount),
+			app.FormatRupiah(m.Revenue),
+		})
+	}
+	return rows
+}
+
+//
+// =========================
+// REKAP PDF
+// =========================
+//
+
+func writeRekapPDF(c *gin.Context, rd *rekapData) {
+	r := newReportPDF("REKAP TRANSAKSI", rd.Stan, periodLabel(rd.From, rd.To))
+
+	r.section("Daftar Transaksi")
+	if len(rd.Transaksis) == 0 {
+		r.SetFont("Arial", "I", 10)
+		r.CellFormat(0, 7, "Tidak ada transaksi pada periode ini.", "", 1, "", false, 0, "")
+	} else {
+		r.table(pdfTrxColumns, pdfTrxRows(rd.Transaksis, rd.SiswaNames))
+		r.totalRow(pdfTrxColumns, "TOTAL PEMASUKAN", app.FormatRupiah(rd.Total))
+	}
+
+	menus := aggregateMenus(rd.Transaksis)
+	if len(menus) > 0 {
+		r.section("Rincian per Menu")
+		r.table(pdfMenuColumns, pdfMenuRows(menus))
+		r.voucherRow(pdfMenuColumns, rd.Voucher)
+		r.totalRow(pdfMenuColumns, "TOTAL", app.FormatRupiah(rd.Total))
+	}
+
+	r.section("Ringkasan")
+	r.keyValues([][2]string{
+		{"Total Transaksi", strconv.Itoa(len(rd.Transaksis))},
+		{"Subtotal Item", app.FormatRupiah(rd.ItemsTotal)},
+		{"Potongan Voucher", app.FormatRupiah(rd.Voucher)},
+		{"Total Pemasukan", app.FormatRupiah(rd.Total)},
+	})
+
+	r.signature(rd.Stan)
+
+	sendPDF(c, r, exportFilename("rekap", rd.Stan, rangeSuffix(rd.From, rd.To), "pdf"))
+}
+
+//
+// =========================
+// MONTHLY PDF
+// =========================
+//
+
+func writeMonthlyPDF(c *gin.Context, stan app.Stan, month string, rep *stanReport, names map[uint]string) {
+	last := rep.End.Add(-time.Second)
+	periode := app.FormatDateID(&rep.Start, false) + " - " + app.FormatDateID(&last, false)
+	r := newReportPDF("LAPORAN BULANAN "+month, stan, periode)
+
+	s := rep.Summary
+	r.section("Ringkasan")
+	r.keyValues([][2]string{
+		{"Total Order", strconv.Itoa(s.Orders)},
+		{"Item Terjual", strconv.Itoa(s.ItemsSold)},
+		{"Harga Normal", app.FormatRupiah(s.Gross)},
+		{"Total Diskon", app.FormatRupiah(s.Discount)},
+		{"Potongan Voucher", app.FormatRupiah(s.Voucher)},
+		{"Pendapatan", app.FormatRupiah(s.Revenue)},
+		{"Rata-rata Transaksi", app.FormatRupiah(s.AvgBasket)},
+	})
+
+	if len(rep.Menus) > 0 {
+		r.section("Rincian per Menu")
+		r.table(pdfMenuColumns, pdfMenuRows(rep.Menus))
+		r.voucherRow(pdfMenuColumns, s.Voucher)
+		r.totalRow(pdfMenuColumns, "TOTAL", app.FormatRupiah(s.Revenue))
+	}
+
+	r.section("Daftar Transaksi")
+	if len(rep.Transaksis) == 0 {
+		r.SetFont("Arial", "I", 10)
+		r.CellFormat(0, 7, "Tidak ada transaksi pada bulan ini.", "", 1, "", false, 0, "")
+	} else {
+		r.table(pdfTrxColumns, pdfTrxRows(rep.Transaksis, names))
+		r.totalRow(pdfTrxColumns, "TOTAL PEMASUKAN", app.FormatRupiah(s.Revenue))
+	}
+
+	r.signature(stan)
+
+	sendPDF(c, r, exportFilename("laporan", stan, month, "pdf"))
+}
@@ -0,0 +1,229 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// PICKUP SLOT (SUPER ADMIN)
+// =========================
+//
+
+type createPickupSlotPayload struct {
+	Nama        string `json:"nama" binding:"required"` // mis. "Istirahat 1"
+	JamMulai    string `json:"jam_mulai" binding:"required"`
+	JamSelesai  string `json:"jam_selesai" binding:"required"`
+	Kapasitas   int    `json:"kapasitas,omitempty"`    // per stan per hari, 0 = tanpa batas
+	CutoffMenit int    `json:"cutoff_menit,omitempty"` // order ditutup N menit sebelum jam_mulai
+	IsActive    *bool  `json:"is_active,omitempty"`
+}
+
+type updatePickupSlotPayload struct {
+	Nama        *string `json:"nama,omitempty"`
+	JamMulai    *string `json:"jam_mulai,omitempty"`
+	JamSelesai  *string `json:"jam_selesai,omitempty"`
+	Kapasitas   *int    `json:"kapasitas,omitempty"`
+	CutoffMenit *int    `json:"cutoff_menit,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+func pickupSlotJSON(s app.PickupSlot) gin.H {
+	return gin.H{
+		"slot_id":      s.PublicID,
+		"nama":         s.Nama,
+		"jam_mulai":    s.JamMulai,
+		"jam_selesai":  s.JamSelesai,
+		"kapasitas":    s.Kapasitas,
+		"cutoff_menit": s.CutoffMenit,
+		"is_active":    s.IsActive,
+		"created_at":   s.CreatedAt,
+	}
+}
+
+func findPickupSlot(c *gin.Context) (*app.PickupSlot, bool) {
+	var s app.PickupSlot
+	if err := app.DB.Where("public_id = ?", c.Param("id")).First(&s).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "pickup slot not found"})
+		return nil, false
+	}
+	return &s, true
+}
+
+// pickupSlotNameTaken true jika nama sudah dipakai slot lain
+func pickupSlotNameTaken(nama string, exceptID uint) bool {
+	var count int64
+	app.DB.Model(&app.PickupSlot{}).
+		Where("nama = ? AND id <> ?", nama, exceptID).
+		Count(&count)
+	return count > 0
+}
+
+// normalizePickupJam "9:30" → "09:30"
+func normalizePickupJam(field, s string) (string, error) {
+	jam, err := normalizeJam(s)
+	if err != nil || jam == "" {
+		return "", fmt.Errorf("invalid %s, use HH:MM", field)
+	}
+	return jam, nil
+}
+
+// POST /api/admin/pickup-slots
+func AdminCreatePickupSlot(c *gin.Context) {
+	var p createPickupSlotPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	mulai, err := normalizePickupJam("jam_mulai", p.JamMulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	selesai, err := normalizePickupJam("jam_selesai", p.JamSelesai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	active := true
+	if p.IsActive != nil {
+		active = *p.IsActive
+	}
+
+	s := app.PickupSlot{
+		Nama:        p.Nama,
+		JamMulai:    mulai,
+		JamSelesai:  selesai,
+		Kapasitas:   p.Kapasitas,
+		CutoffMenit: p.CutoffMenit,
+		IsActive:    active,
+	}
+	if err := app.ValidatePickupSlot(&s); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if pickupSlotNameTaken(s.Nama, 0) {
+		c.JSON(http.StatusConflict, gin.H{"error": "pickup slot already exists"})
+		return
+	}
+
+	if err := app.DB.Create(&s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create pickup slot"})
+		return
+	}
+	// default:true di tag → false harus di-set terpisah
+	if !active {
+		app.DB.Model(&s).UpdateColumn("is_active", false)
+	}
+
+	c.JSON(http.StatusCreated, pickupSlotJSON(s))
+}
+
+// GET /api/admin/pickup-slots
+func AdminListPickupSlots(c *gin.Context) {
+	var slots []app.PickupSlot
+	if err := app.DB.Order("jam_mulai ASC, nama ASC").Find(&slots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list pickup slots"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(slots))
+	for _, s := range slots {
+		out = append(out, pickupSlotJSON(s))
+	}
+
+	c.JSON(http.StatusOK, gin.H{"pickup_slots": out})
+}
+
+// PUT /api/admin/pickup-slots/:id
+// Perubahan jam tidak menggeser pickup_at order yang sudah dibuat.
+func AdminUpdatePickupSlot(c *gin.Context) {
+	s, ok := findPickupSlot(c)
+	if !ok {
+		return
+	}
+
+	var p updatePickupSlotPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if p.Nama != nil {
+		s.Nama = strings.TrimSpace(*p.Nama)
+		if pickupSlotNameTaken(s.Nama, s.ID) {
+			c.JSON(http.StatusConflict, gin.H{"error": "pickup slot already exists"})
+			return
+		}
+	}
+	if p.JamMulai != nil {
+		jam, err := normalizePickupJam("jam_mulai", *p.JamMulai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.JamMulai = jam
+	}
+	if p.JamSelesai != nil {
+		jam, err := normalizePickupJam("jam_selesai", *p.JamSelesai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		s.JamSelesai = jam
+	}
+	if p.Kapasitas != nil {
+		s.Kapasitas = *p.Kapasitas
+	}
+	if p.CutoffMenit != nil {
+		s.CutoffMenit = *p.CutoffMenit
+	}
+	if p.IsActive != nil {
+		s.IsActive = *p.IsActive
+	}
+
+	if err := app.ValidatePickupSlot(s); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Save menulis semua kolom (termasuk is_active=false & kapasitas=0)
+	if err := app.DB.Save(s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update pickup slot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pickupSlotJSON(*s))
+}
+
+// DELETE /api/admin/pickup-slots/:id
+// Slot yang sudah dipakai order tidak bisa dihapus (riwayat order) → nonaktifkan saja.
+func AdminDeletePickupSlot(c *gin.Context) {
+	s, ok := findPickupSlot(c)
+	if !ok {
+		return
+	}
+
+	var used int64
+	app.DB.Model(&app.Transaksi{}).Where("pickup_slot_id = ?", s.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "pickup slot sudah dipakai order, nonaktifkan dengan is_active=false",
+		})
+		return
+	}
+
+	if err := app.DB.Delete(s).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete pickup slot"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "pickup slot deleted"})
+}
@@ -0,0 +1,76 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// RECEIPT (STRUK) — ADMIN STAN
+// =========================
+//
+// Admin stan bisa mencetak ulang struk untuk order mana pun milik stannya.
+// Default kertas 80mm (printer kasir).
+//
+
+// loadStanReceipt mengambil order milik stan admin yang login → struk.
+// Response error sudah dikirim jika ok = false.
+func loadStanReceipt(c *gin.Context) (app.Receipt, int, string, bool) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return app.Receipt{}, 0, "", false
+	}
+
+	paper, err := app.ParsePaperWidth(c.Query("paper"), app.Paper80)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return app.Receipt{}, 0, "", false
+	}
+
+	var trx app.Transaksi
+	if err := app.DB.
+		Preload("Details.Menu").
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		First(&trx).Error; err != nil {
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "order not found"})
+		return app.Receipt{}, 0, "", false
+	}
+
+	names := siswaNamesByID([]uint{trx.SiswaID})
+	return app.NewReceipt(trx, *stan, names[trx.SiswaID]), paper, trx.PublicID, true
+}
+
+// GET /api/admin/orders/:id/receipt/pdf?paper=58|80
+func AdminOrderReceiptPDF(c *gin.Context) {
+	rc, paper, id, ok := loadStanReceipt(c)
+	if !ok {
+		return
+	}
+
+	out, err := rc.ThermalPDF(paper)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate pdf"})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("inline; filename=struk-%s-%dmm.pdf", id, paper))
+	c.Data(http.StatusOK, "application/pdf", out)
+}
+
+// GET /api/admin/orders/:id/receipt/escpos?paper=58|80
+// Byte mentah ESC/POS, bisa langsung diteruskan ke printer thermal USB.
+func AdminOrderReceiptESCPOS(c *gin.Context) {
+	rc, paper, id, ok := loadStanReceipt(c)
+	if !ok {
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=struk-%s-%dmm.bin", id, paper))
+	c.Data(http.StatusOK, "application/octet-stream", rc.ESCPOS(paper))
+}
@@ -0,0 +1,79 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Payload
+// =========================
+//
+
+type registerKasirPayload struct {
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+//
+// =========================
+// Handler
+// =========================
+//
+
+// RegisterKasir -> POST /api/admin/system/kasir
+// 🔒 ONLY super_admin
+// Kasir = operator yang boleh top-up saldo siswa.
+func RegisterKasir(c *gin.Context) {
+	// defense-in-depth
+	roleAny, ok := c.Get("role")
+	if !ok || roleAny.(string) != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "super admin only"})
+		return
+	}
+
+	var p registerKasirPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var ex app.User
+	if err := app.DB.Where("email = ?", p.Email).First(&ex).Error; err == nil {
+		c.JSON(http.StatusConflict, gin.H{"error": "email already exists"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(p.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	creatorID, _ := getUserIDFromContext(c)
+
+	user := app.User{
+		Email:              p.Email,
+		PasswordHash:       string(hash),
+		Role:               app.RoleKasir,
+		MustChangePassword: true,
+		CreatedBy:          &creatorID,
+	}
+	if err := app.DB.Create(&user).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create user"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":              "register kasir success",
+		"user_id":              user.PublicID,
+		"email":                user.Email,
+		"role":                 user.Role,
+		"must_change_password": user.MustChangePassword,
+	})
+}
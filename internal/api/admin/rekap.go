@@ -2,6 +2,7 @@ package admin
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -9,8 +10,77 @@ import (
 	"github.com/samudsamudra/UKK_kantin/internal/app"
 )
 
+// rekapData hasil query rekap (dipakai JSON & export)
+type rekapData struct {
+	Stan       app.Stan
+	From       *time.Time // UTC, inklusif (nil = tanpa batas)
+	To         *time.Time // UTC, eksklusif (nil = tanpa batas)
+	Transaksis []app.Transaksi
+	SiswaNames map[uint]string
+	ItemsTotal float64 // subtotal item (setelah diskon otomatis, sebelum voucher)
+	Voucher    float64 // total potongan voucher
+	Total      float64 // ItemsTotal - Voucher (pemasukan bersih)
+}
+
+// loadRekap mengambil transaksi SUDAH SAMPAI milik stan dalam rentang [from, to).
+// Order dibatalkan / ditolak TIDAK dihitung pemasukan.
+func loadRekap(stan app.Stan, from, to *time.Time) (*rekapData, error) {
+	q := app.DB.
+		Preload("Details.Menu").
+		Where("stan_id = ? AND status = ?", stan.ID, app.StatusSampai)
+	if from != nil {
+		q = q.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		q = q.Where("created_at < ?", *to)
+	}
+
+	var transaksis []app.Transaksi
+	err := q.
+		Order("created_at ASC"). // TERLAMA → TERBARU
+		Find(&transaksis).Error
+	if err != nil && err != gorm.ErrRecordNotFound {
+		return nil, err
+	}
+
+	ids := make([]uint, 0, len(transaksis))
+	for _, trx := range transaksis {
+		ids = append(ids, trx.SiswaID)
+	}
+
+	rd := &rekapData{
+		Stan:       stan,
+		From:       from,
+		To:         to,
+		Transaksis: transaksis,
+		SiswaNames: siswaNamesByID(ids),
+	}
+	for _, trx := range transaksis {
+		rd.ItemsTotal += trx.ItemsTotal()
+		rd.Voucher += trxVoucher(trx)
+		rd.Total += trxTotal(trx)
+	}
+	return rd, nil
+}
+
+// trxTotal total 1 transaksi (harga beli × qty, dikurangi potongan voucher)
+func trxTotal(trx app.Transaksi) float64 {
+	return trx.Total()
+}
+
+// trxVoucher potongan voucher yang benar-benar mengurangi total 1 transaksi
+func trxVoucher(trx app.Transaksi) float64 {
+	return trx.ItemsTotal() - trx.Total()
+}
+
 // GET /api/admin/reports/rekap
 // Rekap transaksi yang SUDAH SAMPAI (urut lama → terbaru)
+// Order dibatalkan / ditolak TIDAK dihitung pemasukan.
+// optional:
+//
+//	?from=YYYY-MM-DD&to=YYYY-MM-DD (WIB, inklusif)
+//	?format=csv|xlsx|pdf           (default JSON)
+//	?rows=detail                   (export: 1 baris per item, default per transaksi)
 func AdminRekapTransaksi(c *gin.Context) {
 	user, ok := getUserFromContext(c)
 	if !ok {
@@ -28,53 +98,76 @@ func AdminRekapTransaksi(c *gin.Context) {
 		return
 	}
 
+	from, to, err := app.ParseDateRangeJakarta(c.Query("from"), c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, use json|csv|xlsx|pdf"})
+		return
+	}
+
 	// =========================
 	// Ambil transaksi (HANYA yang sudah sampai)
 	// =========================
-	var transaksis []app.Transaksi
-	err := app.DB.
-		Preload("Details").
-		Where(
-			"stan_id = ? AND status = ?",
-			stan.ID,
-			app.StatusSampai,
-		).
-		Order("created_at ASC"). // TERLAMA → TERBARU
-		Find(&transaksis).Error
-
-	if err != nil && err != gorm.ErrRecordNotFound {
+	rd, err := loadRekap(stan, from, to)
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch transactions"})
 		return
 	}
 
 	// =========================
-	// Hitung rekap
+	// Export spreadsheet / PDF
 	// =========================
-	var totalPemasukan float64
-	out := make([]gin.H, 0, len(transaksis))
-
-	for _, trx := range transaksis {
-		var totalTrx float64
-		for _, d := range trx.Details {
-			totalTrx += float64(d.Qty) * d.HargaBeli
-		}
+	perDetail := c.Query("rows") == "detail"
+	switch format {
+	case "csv":
+		writeRekapCSV(c, rd, perDetail)
+		return
+	case "xlsx":
+		writeRekapXLSX(c, rd, perDetail)
+		return
+	case "pdf":
+		writeRekapPDF(c, rd)
+		return
+	}
 
-		totalPemasukan += totalTrx
+	// =========================
+	// Hitung rekap
+	// =========================
+	out := make([]gin.H, 0, len(rd.Transaksis))
 
+	for _, trx := range rd.Transaksis {
 		out = append(out, gin.H{
 			"transaksi_id": trx.PublicID,
 			"tanggal":      trx.CreatedAt, // raw timestamp (aman)
 			"tanggal_real": trx.CreatedAt.Format("02 Jan 2006 15:04"),
-			"total":        app.Round2(totalTrx),
+			"total":        app.Round2(trxTotal(trx)),
 		})
 	}
 
+	// info saja: jumlah order batal / ditolak (rentang tanggal sama)
+	var totalBatal int64
+	qBatal := app.DB.Model(&app.Transaksi{}).
+		Where("stan_id = ? AND status IN ?", stan.ID, app.CancelledStatuses)
+	if from != nil {
+		qBatal = qBatal.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		qBatal = qBatal.Where("created_at < ?", *to)
+	}
+	qBatal.Count(&totalBatal)
+
 	// =========================
 	// Response rapi
 	// =========================
 	c.JSON(http.StatusOK, gin.H{
-		"total_transaksi": len(transaksis),
-		"total_pemasukan": app.Round2(totalPemasukan),
+		"total_transaksi": len(rd.Transaksis),
+		"total_pemasukan": app.Round2(rd.Total),
+		"total_batal":     totalBatal,
 		"orders":          out, // urut lama → terbaru
 	})
 }
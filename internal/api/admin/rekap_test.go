@@ -0,0 +1,59 @@
+package admin
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+func TestRekapTotalBatalRespectsRange(t *testing.T) {
+	db := apptest.OpenDB(t)
+	admin, stan := seedAdminStan(t, db)
+	_, siswa := apptest.SeedSiswa(t, db, 0)
+
+	now := time.Now()
+	old := now.AddDate(0, 0, -10)
+	for _, o := range []struct {
+		status app.TransaksiStatus
+		at     time.Time
+	}{
+		{app.StatusDibatalkan, now},
+		{app.StatusDitolak, now},
+		{app.StatusDibatalkan, old}, // di luar rentang
+	} {
+		if err := db.Create(&app.Transaksi{
+			StanID:        stan.ID,
+			SiswaID:       siswa.ID,
+			Status:        o.status,
+			PaymentMethod: app.PaymentCash,
+			PaymentStatus: app.PaymentVoid,
+			CreatedAt:     o.at,
+		}).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	day := now.In(app.JakartaLoc()).Format("2006-01-02")
+	cases := []struct {
+		name   string
+		target string
+		batal  float64
+	}{
+		{"semua periode", "/", 3},
+		{"hari ini", "/?from=" + day + "&to=" + day, 2},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := callHandler(t, AdminRekapTransaksi, admin.ID, http.MethodGet, tc.target, nil)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+			}
+			if got := decodeBody(t, w)["total_batal"]; got != tc.batal {
+				t.Errorf("total_batal = %v, want %v", got, tc.batal)
+			}
+		})
+	}
+}
@@ -1,7 +1,299 @@
 package admin
 
-import "github.com/gin-gonic/gin"
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// REPORT TYPES
+// =========================
+//
+
+type reportDay struct {
+	Date    string // YYYY-MM-DD (WIB)
+	Orders  int
+	Revenue float64
+}
+
+type reportMenu struct {
+	MenuID   string
+	Nama     string
+	Qty      int
+	Revenue  float64
+	Discount float64
+}
+
+type reportSummary struct {
+	Orders    int
+	ItemsSold int
+	Gross     float64 // harga normal (snapshot HargaNormal × qty)
+	Revenue   float64 // harga beli (DetailTransaksi.HargaBeli × qty) - potongan voucher
+	Discount  float64 // Gross - Revenue
+	Voucher   float64 // potongan voucher (bagian dari Discount)
+	AvgBasket float64 // Revenue / Orders
+}
+
+type stanReport struct {
+	Start   time.Time // UTC, inklusif
+	End     time.Time // UTC, eksklusif
+	Summary reportSummary
+	Days    []reportDay
+	Menus   []reportMenu // urut revenue terbesar
+
+	Transaksis []app.Transaksi // mentah (untuk export)
+}
+
+//
+// =========================
+// REPORT BUILDER
+// =========================
+//
+
+// buildStanReport menghitung laporan stan untuk rentang [start, end).
+// Sama seperti rekap: hanya order yang SUDAH SAMPAI.
+func buildStanReport(stanID uint, start, end time.Time) (*stanReport, error) {
+	var trxs []app.Transaksi
+	if err := app.DB.
+		Preload("Details.Menu").
+		Where(
+			"stan_id = ? AND status = ? AND created_at >= ? AND created_at < ?",
+			stanID, app.StatusSampai, start, end,
+		).
+		Order("created_at ASC").
+		Find(&trxs).Error; err != nil {
+		return nil, err
+	}
+
+	rep := &stanReport{Start: start, End: end, Transaksis: trxs}
+
+	// semua hari dalam rentang, termasuk yang kosong (WIB)
+	loc := app.JakartaLoc()
+	dayIdx := map[string]int{}
+	for d := start.In(loc); d.Before(end); d = d.AddDate(0, 0, 1) {
+		key := d.Format("2006-01-02")
+		dayIdx[key] = len(rep.Days)
+		rep.Days = append(rep.Days, reportDay{Date: key})
+	}
+
+	for _, t := range trxs {
+		var totalTrx float64
+		for _, d := range t.Details {
+			net := float64(d.Qty) * d.HargaBeli
+			totalTrx += net
+			rep.Summary.ItemsSold += d.Qty
+			rep.Summary.Gross += d.GrossSubtotal()
+		}
+		// potongan voucher mengurangi pemasukan stan (masuk ke angka diskon)
+		voucher := trxVoucher(t)
+		totalTrx -= voucher
+		rep.Summary.Voucher += voucher
+
+		rep.Summary.Orders++
+		rep.Summary.Revenue += totalTrx
+
+		key := t.CreatedAt.In(loc).Format("2006-01-02")
+		if i, ok := dayIdx[key]; ok {
+			rep.Days[i].Orders++
+			rep.Days[i].Revenue += totalTrx
+		}
+	}
+
+	rep.Summary.Discount = rep.Summary.Gross - rep.Summary.Revenue
+	if rep.Summary.Orders > 0 {
+		rep.Summary.AvgBasket = rep.Summary.Revenue / float64(rep.Summary.Orders)
+	}
+
+	rep.Menus = aggregateMenus(trxs)
+
+	return rep, nil
+}
+
+// aggregateMenus rekap per menu, urut revenue terbesar
+func aggregateMenus(trxs []app.Transaksi) []reportMenu {
+	menus := []reportMenu{}
+	menuIdx := map[uint]int{}
+	for _, t := range trxs {
+		for _, d := range t.Details {
+			net := float64(d.Qty) * d.HargaBeli
+
+			i, ok := menuIdx[d.MenuID]
+			if !ok {
+				i = len(menus)
+				menuIdx[d.MenuID] = i
+				menus = append(menus, reportMenu{
+					MenuID: d.Menu.PublicID,
+					Nama:   d.MenuName(),
+				})
+			}
+			menus[i].Qty += d.Qty
+			menus[i].Revenue += net
+			menus[i].Discount += d.GrossSubtotal() - net
+		}
+	}
+
+	sort.SliceStable(menus, func(i, j int) bool {
+		return menus[i].Revenue > menus[j].Revenue
+	})
+	return menus
+}
+
+// topMenus mengambil n menu teratas berdasarkan `by` ("qty" / "revenue")
+func topMenus(menus []reportMenu, by string, n int) []gin.H {
+	sorted := append([]reportMenu(nil), menus...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if by == "qty" {
+			return sorted[i].Qty > sorted[j].Qty
+		}
+		return sorted[i].Revenue > sorted[j].Revenue
+	})
+	if len(sorted) > n {
+		sorted = sorted[:n]
+	}
+
+	out := make([]gin.H, 0, len(sorted))
+	for _, m := range sorted {
+		out = append(out, gin.H{
+			"menu_id":      m.MenuID,
+			"nama_makanan": m.Nama,
+			"qty":          m.Qty,
+			"revenue":      app.Round2(m.Revenue),
+			"diskon":       app.Round2(m.Discount),
+		})
+	}
+	return out
+}
+
+func summaryJSON(s reportSummary) gin.H {
+	return gin.H{
+		"total_order":   s.Orders,
+		"item_terjual":  s.ItemsSold,
+		"pendapatan":    app.Round2(s.Revenue),
+		"harga_normal":  app.Round2(s.Gross),
+		"total_diskon":  app.Round2(s.Discount),
+		"total_voucher": app.Round2(s.Voucher),
+		"rata_rata_trx": app.Round2(s.AvgBasket),
+	}
+}
+
+// growth menghitung selisih & persen perubahan (nil jika bulan lalu 0)
+func growth(cur, prev float64) gin.H {
+	var pct interface{} = nil
+	if prev != 0 {
+		pct = app.Round2((cur - prev) / prev * 100)
+	}
+	return gin.H{
+		"selisih": app.Round2(cur - prev),
+		"persen":  pct,
+	}
+}
+
+// resolveReportMonth membaca ?month=YYYY-MM (default: bulan ini, WIB)
+func resolveReportMonth(c *gin.Context) (string, time.Time, time.Time, error) {
+	month := c.Query("month")
+	if month == "" {
+		month = time.Now().In(app.JakartaLoc()).Format("2006-01")
+	}
+	start, end, err := app.ParseMonthJakarta(month)
+	return month, start, end, err
+}
+
+//
+// =========================
+// MONTHLY REPORT (ADMIN STAN)
+// =========================
+//
+
+// GET /api/admin/reports/monthly?month=YYYY-MM&top=5
+// Laporan bulanan stan (WIB) + perbandingan dengan bulan sebelumnya.
+// optional: ?format=csv|xlsx|pdf (download, default JSON)
 func AdminMonthlyReport(c *gin.Context) {
-	c.JSON(200, gin.H{"msg": "admin monthly report OK"})
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	month, start, end, err := resolveReportMonth(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" && format != "xlsx" && format != "pdf" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid format, use json|csv|xlsx|pdf"})
+		return
+	}
+
+	top := 5
+	if v, err := strconv.Atoi(c.Query("top")); err == nil && v > 0 && v <= 50 {
+		top = v
+	}
+
+	rep, err := buildStanReport(stan.ID, start, end)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	switch format {
+	case "csv":
+		writeMonthlyCSV(c, *stan, month, rep)
+		return
+	case "xlsx":
+		writeMonthlyXLSX(c, *stan, month, rep)
+		return
+	case "pdf":
+		ids := make([]uint, 0, len(rep.Transaksis))
+		for _, t := range rep.Transaksis {
+			ids = append(ids, t.SiswaID)
+		}
+		writeMonthlyPDF(c, *stan, month, rep, siswaNamesByID(ids))
+		return
+	}
+
+	prevStart := start.In(app.JakartaLoc()).AddDate(0, -1, 0).UTC()
+	prev, err := buildStanReport(stan.ID, prevStart, start)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to build report"})
+		return
+	}
+
+	days := make([]gin.H, 0, len(rep.Days))
+	for _, d := range rep.Days {
+		days = append(days, gin.H{
+			"tanggal":     d.Date,
+			"total_order": d.Orders,
+			"pendapatan":  app.Round2(d.Revenue),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stan_id":   stan.PublicID,
+		"nama_stan": stan.NamaStan,
+		"month":     month,
+
+		"summary":        summaryJSON(rep.Summary),
+		"per_hari":       days,
+		"top_qty":        topMenus(rep.Menus, "qty", top),
+		"top_pendapatan": topMenus(rep.Menus, "revenue", top),
+
+		"bulan_lalu": gin.H{
+			"month":   prevStart.In(app.JakartaLoc()).Format("2006-01"),
+			"summary": summaryJSON(prev.Summary),
+		},
+		"perbandingan": gin.H{
+			"pendapatan":    growth(rep.Summary.Revenue, prev.Summary.Revenue),
+			"total_order":   growth(float64(rep.Summary.Orders), float64(prev.Summary.Orders)),
+			"rata_rata_trx": growth(rep.Summary.AvgBasket, prev.Summary.AvgBasket),
+			"total_diskon":  growth(rep.Summary.Discount, prev.Summary.Discount),
+		},
+	})
 }
@@ -0,0 +1,74 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// panjang password sementara hasil reset
+const tempPasswordLength = 10
+
+// AdminResetPassword
+// POST /api/admin/system/users/:id/reset-password
+// 🔒 SUPER ADMIN ONLY
+// Set password baru (one-time) + wajib ganti saat login berikutnya.
+func AdminResetPassword(c *gin.Context) {
+	// defense-in-depth
+	roleAny, ok := c.Get("role")
+	if !ok || roleAny.(string) != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "super admin only"})
+		return
+	}
+
+	var user app.User
+	if err := app.DB.Where("public_id = ?", c.Param("id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	if user.Role == app.RoleSuperAdmin {
+		c.JSON(http.StatusForbidden, gin.H{"error": "cannot reset super admin password"})
+		return
+	}
+
+	tempPassword, err := app.RandomPassword(tempPasswordLength)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate password"})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(tempPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to hash password"})
+		return
+	}
+
+	// reset password + matikan semua sesi lama user
+	if err := app.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&app.User{}).
+			Where("id = ?", user.ID).
+			Updates(map[string]interface{}{
+				"password_hash":        string(hash),
+				"must_change_password": true,
+			}).Error; err != nil {
+			return err
+		}
+		return app.RevokeAllSessions(tx, user.ID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to reset password"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "password reset",
+		"user_id":              user.PublicID,
+		"email":                user.Email,
+		"temporary_password":   tempPassword,
+		"must_change_password": true,
+	})
+}
@@ -0,0 +1,200 @@
+package admin
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// JAM BUKA & TUTUP SEMENTARA (ADMIN STAN)
+// =========================
+//
+
+type jamBukaItem struct {
+	Hari     string `json:"hari" binding:"required"` // senin … minggu
+	JamBuka  string `json:"jam_buka" binding:"required"`
+	JamTutup string `json:"jam_tutup" binding:"required"`
+}
+
+type setJamBukaPayload struct {
+	// kosong = selalu buka
+	JamBuka []jamBukaItem `json:"jam_buka" binding:"dive"`
+}
+
+type createClosurePayload struct {
+	Mulai   *string `json:"mulai,omitempty"` // default: sekarang
+	Selesai *string `json:"selesai" binding:"required"`
+	Alasan  string  `json:"alasan,omitempty"`
+}
+
+// GET /api/admin/stan/hours
+func AdminGetStanHours(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	schedules, err := app.LoadStanSchedules(app.DB, []uint{stan.ID}, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load jam buka"})
+		return
+	}
+
+	out := schedules[stan.ID].ScheduleJSON(now)
+	out["stan_id"] = stan.PublicID
+	c.JSON(http.StatusOK, out)
+}
+
+// PUT /api/admin/stan/hours
+// Mengganti seluruh jadwal mingguan. Boleh >1 slot per hari (mis. istirahat siang).
+func AdminSetStanHours(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var p setJamBukaPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	hours := make([]app.StanJamBuka, 0, len(p.JamBuka))
+	for _, it := range p.JamBuka {
+		hari, err := app.ParseHari(it.Hari)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		buka, err := normalizeJam(it.JamBuka)
+		if err != nil || buka == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_buka, use HH:MM"})
+			return
+		}
+		tutup, err := normalizeJam(it.JamTutup)
+		if err != nil || tutup == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jam_tutup, use HH:MM"})
+			return
+		}
+		hours = append(hours, app.StanJamBuka{
+			StanID:   stan.ID,
+			Hari:     int(hari),
+			JamBuka:  buka,
+			JamTutup: tutup,
+		})
+	}
+	if err := app.ValidateJamBuka(hours); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := app.DB.Begin()
+	if err := tx.Where("stan_id = ?", stan.ID).Delete(&app.StanJamBuka{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update jam buka"})
+		return
+	}
+	if len(hours) > 0 {
+		if err := tx.Create(&hours).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update jam buka"})
+			return
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update jam buka"})
+		return
+	}
+
+	app.SortJamBuka(hours)
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "jam buka updated",
+		"jam_buka": app.JamBukaJSON(hours),
+	})
+}
+
+// POST /api/admin/stan/closures
+// Tutup sementara, mis. {"selesai":"2026-01-02 07:00","alasan":"libur semester"}
+func AdminCreateStanClosure(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var p createClosurePayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now().UTC()
+	mulai := &now
+	if p.Mulai != nil && strings.TrimSpace(*p.Mulai) != "" {
+		t, err := parseOptionalTime(p.Mulai)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid mulai"})
+			return
+		}
+		mulai = t
+	}
+	selesai, err := parseOptionalTime(p.Selesai)
+	if err != nil || selesai == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid selesai"})
+		return
+	}
+	if !selesai.After(*mulai) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "selesai must be after mulai"})
+		return
+	}
+	if !selesai.After(now) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "selesai must be in the future"})
+		return
+	}
+
+	alasan := strings.TrimSpace(p.Alasan)
+	if r := []rune(alasan); len(r) > 255 {
+		alasan = string(r[:255])
+	}
+
+	closure := app.StanTutup{
+		StanID:  stan.ID,
+		Mulai:   *mulai,
+		Selesai: *selesai,
+		Alasan:  alasan,
+	}
+	if err := app.DB.Create(&closure).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create closure"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, app.ClosureJSON(closure))
+}
+
+// DELETE /api/admin/stan/closures/:id (buka lagi lebih awal)
+func AdminDeleteStanClosure(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	res := app.DB.
+		Where("public_id = ? AND stan_id = ?", c.Param("id"), stan.ID).
+		Delete(&app.StanTutup{})
+	if res.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete closure"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "closure not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "closure deleted"})
+}
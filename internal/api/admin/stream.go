@@ -0,0 +1,19 @@
+package admin
+
+import (
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// GET /api/admin/orders/stream
+// Server-Sent Events: order masuk & perubahan status untuk stan ini.
+// Reconnect: kirim header Last-Event-ID (otomatis oleh EventSource).
+func AdminOrdersStream(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	app.ServeSSE(c, app.TopicStan(stan.ID))
+}
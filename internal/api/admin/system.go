@@ -56,13 +56,28 @@ func AdminClearDatabase(c *gin.Context) {
 		"SET FOREIGN_KEY_CHECKS = 0",
 
 		// transaksi & keuangan
+		"DELETE FROM voucher_redemptions",
 		"DELETE FROM detail_transaksis",
 		"DELETE FROM wallet_transactions",
 		"DELETE FROM transaksis",
+		"DELETE FROM checkouts",
+		"DELETE FROM idempotency_keys",
 
 		// bisnis
+		"DELETE FROM vouchers",
+		"DELETE FROM pickup_slots",
+		"DELETE FROM diskon_menus",
 		"DELETE FROM diskons",
+		"DELETE FROM cart_items",
+		"DELETE FROM menu_tags",
 		"DELETE FROM menus",
+		"DELETE FROM menu_kategoris",
+		"DELETE FROM tags",
+		"DELETE FROM stan_tutups",
+		"DELETE FROM stan_jam_bukas",
+
+		// sesi login
+		"DELETE FROM refresh_tokens",
 
 		// siswa & stan
 		"DELETE FROM siswas",
@@ -0,0 +1,112 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// VERIFY ORDER QR (PICKUP)
+// =========================
+//
+
+// pickupFrom status yang boleh langsung jadi "sampai" saat QR discan di konter
+var pickupFrom = []app.TransaksiStatus{app.StatusDimasak, app.StatusDiantar}
+
+// POST /api/admin/orders/verify
+// body: { "payload": "<isi QR>", "mark_sampai": true }
+// Validasi QR dari struk siswa: tanda tangan asli & order milik stan ini.
+// mark_sampai = true → order (dimasak / diantar) langsung ditandai "sampai".
+func AdminVerifyOrderQR(c *gin.Context) {
+	stan, ok := requireStanOrAbort(c)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		Payload    string `json:"payload" binding:"required"`
+		MarkSampai bool   `json:"mark_sampai"`
+	}
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trxPub, stanPub, err := app.ParseOrderQR(payload.Payload)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"valid": false, "error": "qr tidak valid"})
+		return
+	}
+	if stanPub != stan.PublicID {
+		c.JSON(http.StatusForbidden, gin.H{"valid": false, "error": "order milik stan lain"})
+		return
+	}
+
+	var trx app.Transaksi
+	if err := app.DB.
+		Preload("Details.Menu").
+		Where("public_id = ? AND stan_id = ?", trxPub, stan.ID).
+		First(&trx).Error; err != nil {
+
+		c.JSON(http.StatusNotFound, gin.H{"valid": false, "error": "order not found"})
+		return
+	}
+
+	if trx.Status.IsCancelled() {
+		c.JSON(http.StatusConflict, gin.H{
+			"valid":  false,
+			"error":  "order sudah dibatalkan",
+			"status": trx.Status,
+		})
+		return
+	}
+
+	// tandai sudah diambil
+	marked := false
+	if payload.MarkSampai && trx.Status != app.StatusSampai {
+		// order tunai dibayar di konter saat diambil → ikut lunas
+		if err := app.CompleteTransaksi(app.DB, &trx, pickupFrom); err != nil {
+			if errors.Is(err, app.ErrStatusChanged) {
+				c.JSON(http.StatusConflict, gin.H{
+					"valid":  true,
+					"error":  "order belum bisa diambil",
+					"status": trx.Status,
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update status"})
+			return
+		}
+		marked = true
+		app.PublishOrderEvent(&trx, app.EventOrderStatus)
+	}
+
+	items := make([]gin.H, 0, len(trx.Details))
+	for _, d := range trx.Details {
+		items = append(items, gin.H{
+			"nama_makanan": d.MenuName(),
+			"qty":          d.Qty,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"valid":        true,
+		"marked":       marked,
+		"transaksi_id": trx.PublicID,
+		"short_code":   trx.ShortCode(),
+		"nama_siswa":   siswaNamesByID([]uint{trx.SiswaID})[trx.SiswaID],
+		"status":       trx.Status,
+		"status_label": adminStatusLabel(trx.Status),
+		"total":        app.Round2(trx.Total()),
+		"items":        items,
+
+		"payment_method": trx.PaymentMethod,
+		"payment_status": trx.PaymentStatus,
+		"created_at":     trx.CreatedAt,
+	})
+}
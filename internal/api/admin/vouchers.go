@@ -0,0 +1,548 @@
+package admin
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Payloads
+// =========================
+//
+
+type createVoucherPayload struct {
+	Kode   string  `json:"kode" binding:"required"`
+	Nama   string  `json:"nama" binding:"required"`
+	StanID *string `json:"stan_id,omitempty"` // public id stan, kosong = semua stan
+
+	Tipe        string  `json:"tipe,omitempty"` // persen (default) | nominal
+	Persentase  float64 `json:"persentase,omitempty"`
+	Nominal     float64 `json:"nominal,omitempty"`
+	MaxPotongan float64 `json:"max_potongan,omitempty"`
+	MinBelanja  float64 `json:"min_belanja,omitempty"`
+
+	KuotaTotal   int  `json:"kuota_total,omitempty"`
+	KuotaPerUser *int `json:"kuota_per_user,omitempty"` // default 1
+
+	TanggalAwal  *string `json:"tanggal_awal,omitempty"`
+	TanggalAkhir *string `json:"tanggal_akhir,omitempty"`
+	IsActive     *bool   `json:"is_active,omitempty"`
+}
+
+type updateVoucherPayload struct {
+	Nama   *string `json:"nama,omitempty"`
+	StanID *string `json:"stan_id,omitempty"` // "" = semua stan
+
+	Tipe        *string  `json:"tipe,omitempty"`
+	Persentase  *float64 `json:"persentase,omitempty"`
+	Nominal     *float64 `json:"nominal,omitempty"`
+	MaxPotongan *float64 `json:"max_potongan,omitempty"`
+	MinBelanja  *float64 `json:"min_belanja,omitempty"`
+
+	KuotaTotal   *int `json:"kuota_total,omitempty"`
+	KuotaPerUser *int `json:"kuota_per_user,omitempty"`
+
+	TanggalAwal  *string `json:"tanggal_awal,omitempty"`
+	TanggalAkhir *string `json:"tanggal_akhir,omitempty"`
+	IsActive     *bool   `json:"is_active,omitempty"`
+}
+
+//
+// =========================
+// Helpers
+// =========================
+//
+
+var voucherKodeRe = regexp.MustCompile(`^[A-Z0-9_-]{3,32}$`)
+
+// resolveVoucherStan public id stan → id internal ("" → nil = global)
+func resolveVoucherStan(pub string) (*uint, string, error) {
+	pub = strings.TrimSpace(pub)
+	if pub == "" {
+		return nil, "", nil
+	}
+	var stan app.Stan
+	if err := app.DB.Where("public_id = ?", pub).First(&stan).Error; err != nil {
+		return nil, "", fmt.Errorf("stan not found")
+	}
+	return &stan.ID, stan.PublicID, nil
+}
+
+// validateVoucher aturan dasar voucher (create & update)
+func validateVoucher(v *app.Voucher) error {
+	if !voucherKodeRe.MatchString(v.Kode) {
+		return fmt.Errorf("kode must be 3-32 characters (A-Z, 0-9, - or _)")
+	}
+	if strings.TrimSpace(v.Nama) == "" {
+		return fmt.Errorf("nama is required")
+	}
+	switch v.Tipe {
+	case app.DiskonPersen:
+		if v.Persentase <= 0 || v.Persentase > 100 {
+			return fmt.Errorf("persentase must be between 0 and 100")
+		}
+	case app.DiskonNominal:
+		if v.Nominal <= 0 {
+			return fmt.Errorf("nominal must be greater than 0")
+		}
+	default:
+		return fmt.Errorf("invalid tipe, use persen|nominal")
+	}
+	if v.MaxPotongan < 0 || v.MinBelanja < 0 {
+		return fmt.Errorf("max_potongan and min_belanja must not be negative")
+	}
+	if v.KuotaTotal < 0 || v.KuotaPerUser < 0 {
+		return fmt.Errorf("kuota must not be negative")
+	}
+	if v.TanggalAwal != nil && v.TanggalAkhir != nil && v.TanggalAwal.After(*v.TanggalAkhir) {
+		return fmt.Errorf("tanggal_awal must be before tanggal_akhir")
+	}
+	return nil
+}
+
+// voucherUsage statistik pemakaian (redemption order batal sudah dihapus)
+type voucherUsage struct {
+	VoucherID     uint
+	Redemptions   int64
+	UniqueUsers   int64
+	TotalPotongan float64
+}
+
+func voucherUsageByID(ids []uint) map[uint]voucherUsage {
+	out := map[uint]voucherUsage{}
+	if len(ids) == 0 {
+		return out
+	}
+
+	var rows []voucherUsage
+	app.DB.Model(&app.VoucherRedemption{}).
+		Select("voucher_id, COUNT(*) AS redemptions, COUNT(DISTINCT user_id) AS unique_users, COALESCE(SUM(potongan), 0) AS total_potongan").
+		Where("voucher_id IN ?", ids).
+		Group("voucher_id").
+		Scan(&rows)
+	for _, r := range rows {
+		out[r.VoucherID] = r
+	}
+	return out
+}
+
+func voucherJSON(v app.Voucher, stanPub string, u voucherUsage) gin.H {
+	var sisa interface{} = nil
+	if v.KuotaTotal > 0 {
+		s := v.KuotaTotal - v.Terpakai
+		if s < 0 {
+			s = 0
+		}
+		sisa = s
+	}
+
+	var stanID interface{} = nil
+	if stanPub != "" {
+		stanID = stanPub
+	}
+
+	return gin.H{
+		"voucher_id": v.PublicID,
+		"kode":       v.Kode,
+		"nama":       v.Nama,
+		"stan_id":    stanID, // null = semua stan
+		"is_active":  v.IsActive,
+
+		"tipe":         v.Tipe,
+		"persentase":   v.Persentase,
+		"nominal":      v.Nominal,
+		"max_potongan": v.MaxPotongan,
+		"min_belanja":  v.MinBelanja,
+
+		"kuota_total":    v.KuotaTotal,
+		"kuota_per_user": v.KuotaPerUser,
+		"kuota_sisa":     sisa, // null = tanpa batas
+
+		"tanggal_awal":        app.FormatISOOrNil(v.TanggalAwal),
+		"tanggal_akhir":       app.FormatISOOrNil(v.TanggalAkhir),
+		"tanggal_awal_human":  app.FormatDateID(v.TanggalAwal, false),
+		"tanggal_akhir_human": app.FormatDateID(v.TanggalAkhir, false),
+
+		"usage": gin.H{
+			"terpakai":       v.Terpakai,
+			"unique_users":   u.UniqueUsers,
+			"total_potongan": app.Round2(u.TotalPotongan),
+		},
+
+		"created_at": v.CreatedAt,
+	}
+}
+
+func findVoucher(c *gin.Context) (*app.Voucher, bool) {
+	var v app.Voucher
+	if err := app.DB.Where("public_id = ?", c.Param("id")).First(&v).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "voucher not found"})
+		return nil, false
+	}
+	return &v, true
+}
+
+//
+// =========================
+// CREATE
+// =========================
+//
+
+// POST /api/admin/vouchers (super admin)
+func AdminCreateVoucher(c *gin.Context) {
+	userID, _ := getUserIDFromContext(c)
+
+	var p createVoucherPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tAwal, err := parseOptionalTime(p.TanggalAwal)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal_awal"})
+		return
+	}
+	tAkhir, err := parseOptionalTime(p.TanggalAkhir)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal_akhir"})
+		return
+	}
+	stanID, stanPub, err := resolveVoucherStan(derefStr(p.StanID))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tipe := app.DiskonTipe(strings.ToLower(strings.TrimSpace(p.Tipe)))
+	if tipe == "" {
+		tipe = app.DiskonPersen
+	}
+	perUser := 1
+	if p.KuotaPerUser != nil {
+		perUser = *p.KuotaPerUser
+	}
+	active := true
+	if p.IsActive != nil {
+		active = *p.IsActive
+	}
+
+	v := app.Voucher{
+		Kode:   app.NormalizeVoucherCode(p.Kode),
+		Nama:   strings.TrimSpace(p.Nama),
+		StanID: stanID,
+
+		Tipe:        tipe,
+		Persentase:  p.Persentase,
+		Nominal:     p.Nominal,
+		MaxPotongan: p.MaxPotongan,
+		MinBelanja:  p.MinBelanja,
+
+		KuotaTotal:   p.KuotaTotal,
+		KuotaPerUser: perUser,
+
+		TanggalAwal:  tAwal,
+		TanggalAkhir: tAkhir,
+		IsActive:     active,
+		CreatedBy:    userID,
+	}
+	if v.Tipe == app.DiskonNominal {
+		v.Persentase = 0
+		v.MaxPotongan = 0
+	}
+
+	if err := validateVoucher(&v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var count int64
+	app.DB.Model(&app.Voucher{}).Where("kode = ?", v.Kode).Count(&count)
+	if count > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "kode voucher sudah dipakai"})
+		return
+	}
+
+	if err := app.DB.Create(&v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create voucher"})
+		return
+	}
+	// default:true di tag → false harus di-set terpisah
+	if !active {
+		app.DB.Model(&v).UpdateColumn("is_active", false)
+	}
+
+	c.JSON(http.StatusCreated, voucherJSON(v, stanPub, voucherUsage{}))
+}
+
+//
+// =========================
+// LIST
+// =========================
+//
+
+// GET /api/admin/vouchers
+// optional: ?stan_id=<public_id> | ?active=true|false
+func AdminListVouchers(c *gin.Context) {
+	q := app.DB.Model(&app.Voucher{})
+
+	if pub := c.Query("stan_id"); pub != "" {
+		stanID, _, err := resolveVoucherStan(pub)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q = q.Where("stan_id = ?", *stanID)
+	}
+	switch c.Query("active") {
+	case "true":
+		q = q.Where("is_active = ?", true)
+	case "false":
+		q = q.Where("is_active = ?", false)
+	}
+
+	var vouchers []app.Voucher
+	if err := q.Order("created_at DESC").Find(&vouchers).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list vouchers"})
+		return
+	}
+
+	ids := make([]uint, 0, len(vouchers))
+	stanIDs := []uint{}
+	for _, v := range vouchers {
+		ids = append(ids, v.ID)
+		if v.StanID != nil {
+			stanIDs = append(stanIDs, *v.StanID)
+		}
+	}
+	usage := voucherUsageByID(ids)
+	stanPubs := app.StanPublicIDs(stanIDs)
+
+	out := make([]gin.H, 0, len(vouchers))
+	var totalPotongan float64
+	var totalRedemptions int64
+	for _, v := range vouchers {
+		stanPub := ""
+		if v.StanID != nil {
+			stanPub = stanPubs[*v.StanID]
+		}
+		u := usage[v.ID]
+		totalPotongan += u.TotalPotongan
+		totalRedemptions += u.Redemptions
+		out = append(out, voucherJSON(v, stanPub, u))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"vouchers": out,
+		"summary": gin.H{
+			"vouchers":       len(vouchers),
+			"redemptions":    totalRedemptions,
+			"total_potongan": app.Round2(totalPotongan),
+		},
+	})
+}
+
+//
+// =========================
+// GET (DETAIL + STATISTIK)
+// =========================
+//
+
+// GET /api/admin/vouchers/:id
+func AdminGetVoucher(c *gin.Context) {
+	v, ok := findVoucher(c)
+	if !ok {
+		return
+	}
+
+	stanPub := ""
+	if v.StanID != nil {
+		stanPub = app.StanPublicIDs([]uint{*v.StanID})[*v.StanID]
+	}
+	out := voucherJSON(*v, stanPub, voucherUsageByID([]uint{v.ID})[v.ID])
+
+	// pemakaian per stan (berguna untuk voucher global)
+	var perStan []struct {
+		StanID        uint
+		Redemptions   int64
+		TotalPotongan float64
+	}
+	app.DB.Table("voucher_redemptions").
+		Select("transaksis.stan_id, COUNT(*) AS redemptions, COALESCE(SUM(voucher_redemptions.potongan), 0) AS total_potongan").
+		Joins("JOIN transaksis ON transaksis.id = voucher_redemptions.transaksi_id").
+		Where("voucher_redemptions.voucher_id = ?", v.ID).
+		Group("transaksis.stan_id").
+		Scan(&perStan)
+
+	stanIDs := make([]uint, 0, len(perStan))
+	for _, s := range perStan {
+		stanIDs = append(stanIDs, s.StanID)
+	}
+	stanPubs := app.StanPublicIDs(stanIDs)
+	byStan := make([]gin.H, 0, len(perStan))
+	for _, s := range perStan {
+		byStan = append(byStan, gin.H{
+			"stan_id":        stanPubs[s.StanID],
+			"redemptions":    s.Redemptions,
+			"total_potongan": app.Round2(s.TotalPotongan),
+		})
+	}
+
+	// 20 pemakaian terakhir
+	var recent []struct {
+		TransaksiID string
+		Email       string
+		NamaSiswa   string
+		Potongan    float64
+		CreatedAt   time.Time
+	}
+	app.DB.Table("voucher_redemptions").
+		Select("transaksis.public_id AS transaksi_id, users.email, siswas.nama AS nama_siswa, voucher_redemptions.potongan, voucher_redemptions.created_at").
+		Joins("JOIN transaksis ON transaksis.id = voucher_redemptions.transaksi_id").
+		Joins("JOIN users ON users.id = voucher_redemptions.user_id").
+		Joins("LEFT JOIN siswas ON siswas.user_id = voucher_redemptions.user_id").
+		Where("voucher_redemptions.voucher_id = ?", v.ID).
+		Order("voucher_redemptions.created_at DESC").
+		Limit(20).
+		Scan(&recent)
+
+	recentOut := make([]gin.H, 0, len(recent))
+	for _, r := range recent {
+		recentOut = append(recentOut, gin.H{
+			"transaksi_id":     r.TransaksiID,
+			"email":            r.Email,
+			"nama_siswa":       r.NamaSiswa,
+			"potongan":         app.Round2(r.Potongan),
+			"created_at":       r.CreatedAt,
+			"created_at_human": app.FormatTimeWithClock(r.CreatedAt),
+		})
+	}
+
+	out["usage_by_stan"] = byStan
+	out["recent_redemptions"] = recentOut
+	c.JSON(http.StatusOK, out)
+}
+
+//
+// =========================
+// UPDATE
+// =========================
+//
+
+// PUT /api/admin/vouchers/:id (kode tidak bisa diubah)
+func AdminUpdateVoucher(c *gin.Context) {
+	v, ok := findVoucher(c)
+	if !ok {
+		return
+	}
+
+	var p updateVoucherPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if p.Nama != nil {
+		v.Nama = strings.TrimSpace(*p.Nama)
+	}
+	if p.StanID != nil {
+		stanID, _, err := resolveVoucherStan(*p.StanID)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		v.StanID = stanID
+	}
+	if p.Tipe != nil {
+		v.Tipe = app.DiskonTipe(strings.ToLower(strings.TrimSpace(*p.Tipe)))
+	}
+	if p.Persentase != nil {
+		v.Persentase = *p.Persentase
+	}
+	if p.Nominal != nil {
+		v.Nominal = *p.Nominal
+	}
+	if p.MaxPotongan != nil {
+		v.MaxPotongan = *p.MaxPotongan
+	}
+	if p.MinBelanja != nil {
+		v.MinBelanja = *p.MinBelanja
+	}
+	if p.KuotaTotal != nil {
+		v.KuotaTotal = *p.KuotaTotal
+	}
+	if p.KuotaPerUser != nil {
+		v.KuotaPerUser = *p.KuotaPerUser
+	}
+	if p.TanggalAwal != nil {
+		t, err := parseOptionalTime(p.TanggalAwal)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal_awal"})
+			return
+		}
+		v.TanggalAwal = t
+	}
+	if p.TanggalAkhir != nil {
+		t, err := parseOptionalTime(p.TanggalAkhir)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tanggal_akhir"})
+			return
+		}
+		v.TanggalAkhir = t
+	}
+	if p.IsActive != nil {
+		v.IsActive = *p.IsActive
+	}
+
+	if err := validateVoucher(v); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Save menulis semua kolom (termasuk is_active=false & stan_id NULL)
+	if err := app.DB.Save(v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update voucher"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "voucher updated"})
+}
+
+//
+// =========================
+// DELETE
+// =========================
+//
+
+// DELETE /api/admin/vouchers/:id
+// Voucher yang sudah pernah dipakai tidak bisa dihapus (riwayat order) → nonaktifkan saja.
+func AdminDeleteVoucher(c *gin.Context) {
+	v, ok := findVoucher(c)
+	if !ok {
+		return
+	}
+
+	var used int64
+	app.DB.Model(&app.VoucherRedemption{}).Where("voucher_id = ?", v.ID).Count(&used)
+	if used > 0 {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "voucher sudah dipakai, nonaktifkan dengan is_active=false",
+		})
+		return
+	}
+
+	if err := app.DB.Delete(v).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to delete voucher"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "voucher deleted"})
+}
@@ -0,0 +1,168 @@
+package admin
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Payload
+// =========================
+//
+
+type walletAdjustPayload struct {
+	UserPublicID string  `json:"user_public_id" binding:"required"`
+	Amount       float64 `json:"amount" binding:"required"` // bertanda: + tambah, - kurangi
+	Note         string  `json:"note" binding:"required"`
+}
+
+//
+// =========================
+// ADJUST SALDO (SUPER ADMIN)
+// =========================
+//
+
+// AdminWalletAdjust
+// POST /api/admin/wallet/adjust
+// 🔒 SUPER ADMIN ONLY
+// Koreksi saldo manual (+/-), alasan wajib diisi.
+func AdminWalletAdjust(c *gin.Context) {
+	// defense-in-depth
+	roleAny, ok := c.Get("role")
+	if !ok || roleAny.(string) != "super_admin" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "super admin only"})
+		return
+	}
+
+	operatorID, ok := getUserIDFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var p walletAdjustPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if app.Round2(p.Amount) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "amount must not be zero"})
+		return
+	}
+
+	var user app.User
+	if err := app.DB.Where("public_id = ?", p.UserPublicID).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	saldo, err := app.PostWalletEntry(tx, &app.WalletTransaction{
+		UserID:     user.ID,
+		OperatorID: &operatorID,
+		Amount:     p.Amount,
+		Type:       app.WalletAdjustment,
+		Note:       p.Note,
+	})
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, app.ErrInsufficientSaldo) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": "saldo cannot become negative",
+				"saldo": app.Round2(saldo),
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to adjust saldo"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "saldo adjusted",
+		"user_public_id": user.PublicID,
+		"amount":         app.Round2(p.Amount),
+		"saldo":          saldo,
+	})
+}
+
+//
+// =========================
+// LEDGER USER (SUPER ADMIN / KASIR)
+// =========================
+//
+
+// AdminWalletLedger
+// GET /api/admin/wallet/:user_id?page=&limit=
+// 🔒 super_admin | kasir
+// Saldo tersimpan vs saldo hasil hitung ulang ledger.
+func AdminWalletLedger(c *gin.Context) {
+	var user app.User
+	if err := app.DB.Where("public_id = ?", c.Param("user_id")).First(&user).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	ledgerSaldo, err := app.LedgerSaldo(app.DB, user.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sum ledger"})
+		return
+	}
+
+	pg := app.ParsePagination(c)
+	q := app.DB.Model(&app.WalletTransaction{}).Where("user_id = ?", user.ID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count wallet transactions"})
+		return
+	}
+
+	var rows []app.WalletTransaction
+	if err := q.
+		Order("created_at DESC, id DESC").
+		Offset(pg.Offset()).
+		Limit(pg.Limit).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wallet transactions"})
+		return
+	}
+
+	out := make([]gin.H, 0, len(rows))
+	for _, w := range rows {
+		out = append(out, gin.H{
+			"wallet_tx_id": w.PublicID,
+			"type":         w.Type,
+			"amount":       app.Round2(w.Amount),
+			"saldo_after":  app.Round2(w.SaldoAfter),
+			"note":         w.Note,
+			"created_at":   w.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"user_public_id": user.PublicID,
+		"email":          user.Email,
+		"saldo":          app.Round2(user.Saldo),
+		"saldo_ledger":   ledgerSaldo,
+		"consistent":     app.Round2(user.Saldo) == ledgerSaldo,
+		"transactions":   out,
+		"pagination":     pg.Meta(total),
+	})
+}
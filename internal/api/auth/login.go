@@ -4,7 +4,6 @@ import (
 	"net/http"
 	"os"
 	"strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/golang-jwt/jwt/v5"
@@ -25,18 +24,18 @@ type loginPayload struct {
 }
 
 type loginResp struct {
-	Token              string `json:"token"`
+	tokenPair
 	UserID             string `json:"user_id"`
 	Role               string `json:"role"`
 	MustChangePassword bool   `json:"must_change_password"`
-	ExpiresAt          int64  `json:"expires_at"`
 	Email              string `json:"email"`
 }
 
 type loginClaims struct {
-	UserID   uint   `json:"user_id"`
-	PublicID string `json:"public_id"`
-	Role     string `json:"role"` // info only
+	UserID       uint   `json:"user_id"`
+	PublicID     string `json:"public_id"`
+	Role         string `json:"role"` // info only
+	TokenVersion int    `json:"ver"`  // dicek JWTAuth vs users.token_version
 	jwt.RegisteredClaims
 }
 
@@ -116,23 +115,9 @@ func Login(c *gin.Context) {
 	}
 
 	// =========================
-	// BUILD JWT
+	// BUILD TOKENS (access + refresh)
 	// =========================
-	exp := time.Now().Add(24 * time.Hour)
-
-	claims := &loginClaims{
-		UserID:   u.ID,
-		PublicID: u.PublicID,
-		Role:     string(u.Role),
-		RegisteredClaims: jwt.RegisteredClaims{
-			Subject:   u.PublicID,
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			ExpiresAt: jwt.NewNumericDate(exp),
-		},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signed, err := token.SignedString(getJWTSecret())
+	pair, err := issueTokenPair(app.DB, c, &u, "")
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "server error",
@@ -142,11 +127,10 @@ func Login(c *gin.Context) {
 	}
 
 	c.JSON(http.StatusOK, loginResp{
-		Token:              signed,
+		tokenPair:          *pair,
 		UserID:             u.PublicID,
 		Role:               string(u.Role),
 		MustChangePassword: u.MustChangePassword,
-		ExpiresAt:          exp.Unix(),
 		Email:              u.Email,
 	})
-}
\ No newline at end of file
+}
@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Payload
+// =========================
+//
+
+type changePasswordPayload struct {
+	OldPassword string `json:"old_password" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+//
+// =========================
+// Change Password Handler
+// =========================
+//
+
+// ChangePassword -> POST /api/auth/change-password
+// Boleh diakses akun must_change_password (lihat JWTAuthAllowPasswordChange).
+func ChangePassword(c *gin.Context) {
+	uidv, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := uidv.(uint)
+
+	var p changePasswordPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, app.ValidationErrorResponse(err))
+		return
+	}
+
+	var u app.User
+	if err := app.DB.First(&u, userID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword(
+		[]byte(u.PasswordHash),
+		[]byte(p.OldPassword),
+	); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error":   "password lama salah",
+			"message": "periksa kembali password lama",
+		})
+		return
+	}
+
+	if p.OldPassword == p.NewPassword {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "password baru harus berbeda dari password lama",
+		})
+		return
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(p.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "server error",
+			"message": "gagal memproses password",
+		})
+		return
+	}
+
+	// ganti password → semua sesi lama mati, device ini dapat token baru
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Model(&app.User{}).
+		Where("id = ?", u.ID).
+		Updates(map[string]interface{}{
+			"password_hash":        string(hash),
+			"must_change_password": false,
+		}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "server error",
+			"message": "gagal menyimpan password",
+		})
+		return
+	}
+
+	if err := app.RevokeAllSessions(tx, u.ID); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "server error",
+			"message": "gagal mencabut sesi lama",
+		})
+		return
+	}
+	u.TokenVersion++
+	u.MustChangePassword = false
+
+	pair, err := issueTokenPair(tx, c, &u, "")
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "server error",
+			"message": "gagal membuat token",
+		})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error":   "server error",
+			"message": "commit gagal",
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "password berhasil diganti",
+		"must_change_password": false,
+		"tokens":               pair,
+	})
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Payload
+// =========================
+//
+
+type refreshPayload struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+//
+// =========================
+// Refresh Handler
+// =========================
+//
+
+// Refresh -> POST /api/auth/refresh
+// Tukar refresh token → access token + refresh token BARU (rotation).
+// Refresh token yang sudah dicabut dipakai lagi → seluruh family dicabut.
+func Refresh(c *gin.Context) {
+	var p refreshPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token wajib diisi"})
+		return
+	}
+
+	var rt app.RefreshToken
+	if err := app.DB.
+		Where("token_hash = ?", app.HashToken(p.RefreshToken)).
+		First(&rt).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid refresh token"})
+		return
+	}
+
+	// 🚨 reuse token lama → kemungkinan dicuri, matikan 1 family
+	if rt.RevokedAt != nil {
+		_ = app.RevokeRefreshFamily(app.DB, rt.FamilyID)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+		return
+	}
+
+	if time.Now().After(rt.ExpiresAt) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token expired"})
+		return
+	}
+
+	var u app.User
+	if err := app.DB.First(&u, rt.UserID).Error; err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "user not found"})
+		return
+	}
+
+	// logout-all / ganti password setelah token ini dibuat
+	if u.TokenVersion != rt.TokenVersion {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+		return
+	}
+
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// cabut token lama (kondisional → 2 refresh paralel, hanya 1 yang menang)
+	res := tx.Model(&app.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", rt.ID).
+		Update("revoked_at", time.Now())
+	if res.Error != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to rotate token"})
+		return
+	}
+	if res.RowsAffected == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "refresh token revoked"})
+		return
+	}
+
+	pair, err := issueTokenPair(tx, c, &u, rt.FamilyID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to issue token"})
+		return
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, pair)
+}
+
+//
+// =========================
+// Logout Handlers
+// =========================
+//
+
+// Logout -> POST /api/auth/logout
+// Cabut 1 refresh token (sesi device ini). Selalu 200 supaya
+// tidak bisa dipakai menebak token valid.
+func Logout(c *gin.Context) {
+	var p refreshPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token wajib diisi"})
+		return
+	}
+
+	app.DB.Model(&app.RefreshToken{}).
+		Where("token_hash = ? AND revoked_at IS NULL", app.HashToken(p.RefreshToken)).
+		Update("revoked_at", time.Now())
+
+	c.JSON(http.StatusOK, gin.H{"message": "logout berhasil"})
+}
+
+// LogoutAll -> POST /api/auth/logout-all
+// Keluar dari semua device: semua access & refresh token user mati.
+func LogoutAll(c *gin.Context) {
+	uidv, ok := c.Get("user_id")
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	userID, _ := uidv.(uint)
+
+	if err := app.DB.Transaction(func(tx *gorm.DB) error {
+		return app.RevokeAllSessions(tx, userID)
+	}); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to revoke sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "logout dari semua device berhasil"})
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// Token Config
+// =========================
+//
+
+const (
+	accessTokenTTL  = 15 * time.Minute    // access token (JWT) pendek
+	refreshTokenTTL = 30 * 24 * time.Hour // refresh token (opaque, server-side)
+)
+
+type tokenPair struct {
+	Token            string `json:"token"`
+	ExpiresAt        int64  `json:"expires_at"`
+	RefreshToken     string `json:"refresh_token"`
+	RefreshExpiresAt int64  `json:"refresh_expires_at"`
+}
+
+//
+// =========================
+// Helpers
+// =========================
+//
+
+// signAccessToken membuat JWT HS256 berisi token_version user ("ver").
+func signAccessToken(u *app.User) (string, time.Time, error) {
+	now := time.Now()
+	exp := now.Add(accessTokenTTL)
+
+	claims := &loginClaims{
+		UserID:       u.ID,
+		PublicID:     u.PublicID,
+		Role:         string(u.Role),
+		TokenVersion: u.TokenVersion,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   u.PublicID,
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	signed, err := token.SignedString(getJWTSecret())
+	return signed, exp, err
+}
+
+// newRawRefreshToken 32 byte random, base64url
+func newRawRefreshToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// issueTokenPair membuat access token + refresh token baru.
+// familyID kosong → sesi (login) baru.
+func issueTokenPair(db *gorm.DB, c *gin.Context, u *app.User, familyID string) (*tokenPair, error) {
+	access, accessExp, err := signAccessToken(u)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := newRawRefreshToken()
+	if err != nil {
+		return nil, err
+	}
+
+	if familyID == "" {
+		familyID = uuid.NewString()
+	}
+
+	rt := app.RefreshToken{
+		UserID:       u.ID,
+		TokenHash:    app.HashToken(raw),
+		FamilyID:     familyID,
+		TokenVersion: u.TokenVersion,
+		ExpiresAt:    time.Now().Add(refreshTokenTTL),
+		UserAgent:    truncate(c.Request.UserAgent(), 255),
+		IP:           c.ClientIP(),
+	}
+	if err := db.Create(&rt).Error; err != nil {
+		return nil, err
+	}
+
+	return &tokenPair{
+		Token:            access,
+		ExpiresAt:        accessExp.Unix(),
+		RefreshToken:     raw,
+		RefreshExpiresAt: rt.ExpiresAt.Unix(),
+	}, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
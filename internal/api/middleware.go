@@ -27,6 +27,12 @@ func jwtSecret() []byte {
 	return []byte(sec)
 }
 
+// accessClaims: hanya field yang dipakai middleware
+type accessClaims struct {
+	TokenVersion int `json:"ver"`
+	jwt.RegisteredClaims
+}
+
 //
 // =========================
 // JWT Middleware (SECURE)
@@ -36,9 +42,20 @@ func jwtSecret() []byte {
 // - JWT hanya bukti identitas
 // - Role diambil dari DATABASE
 // - Payload JWT TIDAK dipercaya
+// - Akun must_change_password DIBLOKIR sampai password diganti
 //
 
 func JWTAuth() gin.HandlerFunc {
+	return jwtAuth(false)
+}
+
+// JWTAuthAllowPasswordChange sama seperti JWTAuth, tapi akun
+// must_change_password tetap lolos (khusus endpoint ganti password).
+func JWTAuthAllowPasswordChange() gin.HandlerFunc {
+	return jwtAuth(true)
+}
+
+func jwtAuth(allowMustChange bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		h := c.GetHeader("Authorization")
 		if h == "" {
@@ -57,7 +74,7 @@ func JWTAuth() gin.HandlerFunc {
 		}
 		tokenStr := parts[1]
 
-		claims := &jwt.RegisteredClaims{}
+		claims := &accessClaims{}
 
 		token, err := jwt.ParseWithClaims(
 			tokenStr,
@@ -102,6 +119,25 @@ func JWTAuth() gin.HandlerFunc {
 			return
 		}
 
+		// =========================
+		// TOKEN REVOCATION
+		// =========================
+		// logout-all / ganti password → token_version naik
+		if claims.TokenVersion != user.TokenVersion {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{
+				"error": "token revoked",
+			})
+			return
+		}
+
+		// =========================
+		// FORCED PASSWORD CHANGE
+		// =========================
+		if user.MustChangePassword && !allowMustChange {
+			c.AbortWithStatusJSON(http.StatusForbidden, app.PasswordChangeRequiredResponse())
+			return
+		}
+
 		// =========================
 		// SET CONTEXT (SAFE)
 		// =========================
@@ -113,6 +149,20 @@ func JWTAuth() gin.HandlerFunc {
 	}
 }
 
+// SSETokenFromQuery: EventSource di browser tidak bisa kirim header,
+// jadi token boleh lewat ?access_token= KHUSUS untuk route stream.
+// Pasang SEBELUM JWTAuth.
+func SSETokenFromQuery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if tok := c.Query("access_token"); tok != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+tok)
+			}
+		}
+		c.Next()
+	}
+}
+
 //
 // =========================
 // ROLE GUARD (STRING-BASED, AMAN)
@@ -141,6 +191,31 @@ func RequireRole(expected string) gin.HandlerFunc {
 	}
 }
 
+// RequireAnyRole: lolos jika role ada di daftar roles.
+func RequireAnyRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rv, ok := c.Get("role")
+		if !ok {
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+				"error": "forbidden",
+			})
+			return
+		}
+
+		role, _ := rv.(string)
+		for _, r := range roles {
+			if role == r {
+				c.Next()
+				return
+			}
+		}
+
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{
+			"error": "forbidden",
+		})
+	}
+}
+
 // =========================
 // SUPER ADMIN GUARD (HARD)
 // =========================
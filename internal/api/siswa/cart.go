@@ -0,0 +1,574 @@
+package siswa
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// KERANJANG (SISWA)
+// =========================
+//
+// Keranjang disimpan di server (tabel cart_items), harga TIDAK disimpan:
+// setiap GET / perubahan menghitung ulang lewat engine diskon yang sama
+// dengan checkout. Checkout memecah keranjang jadi 1 Transaksi per stan
+// dalam 1 tx, saldo dipotong sekali (lihat app.Checkout).
+
+const (
+	maxCartItems   = 30 // menu berbeda per keranjang
+	maxCartItemQty = 50
+)
+
+type cartAddPayload struct {
+	MenuID string `json:"menu_id" binding:"required"`
+	Qty    int    `json:"qty" binding:"required,gt=0"`
+}
+
+type cartQtyPayload struct {
+	Qty *int `json:"qty" binding:"required,gte=0"` // 0 = hapus dari keranjang
+}
+
+// CheckoutPayload body POST /api/siswa/cart/checkout
+type CheckoutPayload struct {
+	PaymentMethod string `json:"payment_method" binding:"required,oneof=wallet cash"`
+	// optional: client can send idempotency key header instead
+	IdempotencyKey *string `json:"idempotency_key,omitempty"`
+	// optional: kode promo, dipakai di 1 order (stan voucher / subtotal terbesar)
+	VoucherCode string `json:"voucher_code,omitempty"`
+	// optional: pre-order, slot yang sama untuk semua stan
+	PickupSlot string `json:"pickup_slot,omitempty"`
+}
+
+// loadCart isi keranjang user (urut waktu ditambahkan)
+func loadCart(db *gorm.DB, userID uint) ([]app.CartItem, error) {
+	var items []app.CartItem
+	err := db.
+		Preload("Menu").
+		Where("user_id = ?", userID).
+		Order("id ASC").
+		Find(&items).Error
+	return items, err
+}
+
+// cartJSON keranjang dikelompokkan per stan + harga live
+func cartJSON(items []app.CartItem) (gin.H, error) {
+	now := time.Now()
+
+	type stanGroup struct {
+		stanID uint
+		items  []app.CartItem
+	}
+	groups := []*stanGroup{}
+	byStan := map[uint]*stanGroup{}
+	stanIDs := []uint{}
+	for _, it := range items {
+		g, ok := byStan[it.Menu.StanID]
+		if !ok {
+			g = &stanGroup{stanID: it.Menu.StanID}
+			byStan[it.Menu.StanID] = g
+			groups = append(groups, g)
+			stanIDs = append(stanIDs, it.Menu.StanID)
+		}
+		g.items = append(g.items, it)
+	}
+
+	stans := stansByID(stanIDs)
+	discounts, err := app.LoadActiveDiscountsForStans(app.DB, stanIDs, now)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := app.LoadStanSchedules(app.DB, stanIDs, now)
+	if err != nil {
+		return nil, err
+	}
+
+	var total, totalNormal float64
+	itemCount := 0
+	canCheckout := len(items) > 0
+
+	out := make([]gin.H, 0, len(groups))
+	for _, g := range groups {
+		lines := make([]app.PriceLine, 0, len(g.items))
+		for _, it := range g.items {
+			lines = append(lines, app.PriceLine{Menu: it.Menu, Qty: it.Qty})
+		}
+
+		var subtotal, normal float64
+		outItems := make([]gin.H, 0, len(g.items))
+		for _, pl := range app.PriceLines(discounts[g.stanID], lines, now) {
+			m := pl.Menu
+			sub := float64(pl.Qty) * pl.HargaAkhir
+			subtotal += sub
+			normal += float64(pl.Qty) * pl.HargaNormal
+			itemCount += pl.Qty
+
+			// peringatan sebelum checkout (checkout tetap cek ulang di tx)
+			var warning interface{} = nil
+			switch {
+			case !m.IsAvailable:
+				warning = "menu not available"
+			case m.Stok != nil && *m.Stok < pl.Qty:
+				warning = "stok tidak cukup"
+			}
+			if warning != nil {
+				canCheckout = false
+			}
+
+			outItems = append(outItems, gin.H{
+				"menu_id":       m.PublicID,
+				"name":          m.NamaMakanan,
+				"qty":           pl.Qty,
+				"price":         pl.HargaNormal,
+				"price_final":   pl.HargaAkhir,
+				"diskon":        diskonPreviewJSON(pl),
+				"subtotal":      app.Round2(sub),
+				"stok":          m.Stok,
+				"available":     !m.SoldOut(),
+				"thumbnail_url": nullIfEmpty(m.ThumbURL()),
+				"warning":       warning,
+			})
+		}
+
+		// stan tutup → checkout langsung pasti ditolak (keranjang tanpa slot)
+		st := schedules[g.stanID].StatusAt(now)
+		var closedReason interface{} = nil
+		if !st.Open {
+			closedReason = st.Reason
+			canCheckout = false
+		}
+
+		total += subtotal
+		totalNormal += normal
+		stan := stans[g.stanID]
+		out = append(out, gin.H{
+			"stan": gin.H{
+				"id":   stan.PublicID,
+				"name": stan.NamaStan,
+			},
+			"open_now":      st.Open,
+			"closed_reason": closedReason,
+			"items":         outItems,
+			"subtotal":      app.Round2(subtotal),
+		})
+	}
+
+	return gin.H{
+		"stans":        out,
+		"item_count":   itemCount,
+		"total":        app.Round2(total),
+		"total_normal": app.Round2(totalNormal),
+		"total_diskon": app.Round2(totalNormal - total),
+		"can_checkout": canCheckout,
+	}, nil
+}
+
+// respondCart kirim isi keranjang terbaru
+func respondCart(c *gin.Context, userID uint, status int) {
+	items, err := loadCart(app.DB, userID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load cart"})
+		return
+	}
+	out, err := cartJSON(items)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to price cart"})
+		return
+	}
+	c.JSON(status, out)
+}
+
+// GET /api/siswa/cart
+func SiswaGetCart(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+	respondCart(c, user.ID, http.StatusOK)
+}
+
+// POST /api/siswa/cart/items
+// Menu yang sudah ada di keranjang → qty ditambah.
+func SiswaAddCartItem(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var p cartAddPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var menu app.Menu
+	if err := app.DB.Where("public_id = ?", p.MenuID).First(&menu).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "menu not found"})
+		return
+	}
+	if !menu.IsAvailable {
+		c.JSON(http.StatusConflict, gin.H{"error": "menu not available", "menu_id": menu.PublicID})
+		return
+	}
+
+	var item app.CartItem
+	err := app.DB.Where("user_id = ? AND menu_id = ?", user.ID, menu.ID).First(&item).Error
+	switch {
+	case err == nil:
+		item.Qty += p.Qty
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		var count int64
+		app.DB.Model(&app.CartItem{}).Where("user_id = ?", user.ID).Count(&count)
+		if count >= maxCartItems {
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "cart is full", "max_items": maxCartItems})
+			return
+		}
+		item = app.CartItem{UserID: user.ID, MenuID: menu.ID, Qty: p.Qty}
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load cart"})
+		return
+	}
+
+	if item.Qty > maxCartItemQty {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "qty too large", "max_qty": maxCartItemQty})
+		return
+	}
+
+	if err := app.DB.Save(&item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update cart"})
+		return
+	}
+
+	respondCart(c, user.ID, http.StatusOK)
+}
+
+// PATCH /api/siswa/cart/items/:menu_id {"qty": 3} (0 = hapus)
+func SiswaUpdateCartItem(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var p cartQtyPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if *p.Qty > maxCartItemQty {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "qty too large", "max_qty": maxCartItemQty})
+		return
+	}
+
+	item, ok := findCartItem(c, user.ID)
+	if !ok {
+		return
+	}
+
+	var err error
+	if *p.Qty == 0 {
+		err = app.DB.Delete(item).Error
+	} else {
+		err = app.DB.Model(item).UpdateColumn("qty", *p.Qty).Error
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update cart"})
+		return
+	}
+
+	respondCart(c, user.ID, http.StatusOK)
+}
+
+// DELETE /api/siswa/cart/items/:menu_id
+func SiswaRemoveCartItem(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	item, ok := findCartItem(c, user.ID)
+	if !ok {
+		return
+	}
+	if err := app.DB.Delete(item).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update cart"})
+		return
+	}
+
+	respondCart(c, user.ID, http.StatusOK)
+}
+
+// DELETE /api/siswa/cart (kosongkan)
+func SiswaClearCart(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	if err := app.DB.Where("user_id = ?", user.ID).Delete(&app.CartItem{}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clear cart"})
+		return
+	}
+
+	respondCart(c, user.ID, http.StatusOK)
+}
+
+// findCartItem item keranjang user berdasarkan :menu_id (public id menu)
+func findCartItem(c *gin.Context, userID uint) (*app.CartItem, bool) {
+	var item app.CartItem
+	if err := app.DB.
+		Joins("JOIN menus ON menus.id = cart_items.menu_id").
+		Where("cart_items.user_id = ? AND menus.public_id = ?", userID, c.Param("menu_id")).
+		First(&item).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "item not in cart"})
+		return nil, false
+	}
+	return &item, true
+}
+
+//
+// =========================
+// CHECKOUT (MULTI-STAN)
+// =========================
+//
+
+// POST /api/siswa/cart/checkout
+// Semua order dibuat atomik: 1 stan gagal (stok, tutup, slot penuh, saldo) → tidak ada yang tersimpan.
+func SiswaCheckoutCart(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var p CheckoutPayload
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// 🔁 idempotency: request ulang dengan key sama → response asli
+	idemKey := resolveIdempotencyKey(c.GetHeader(idempotencyHeader), p.IdempotencyKey)
+	var idemHash string
+	if idemKey != "" {
+		if len(idemKey) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "idempotency key too long"})
+			return
+		}
+		idemHash = hashCheckoutPayload(p)
+		if prev := findIdempotencyKey(app.DB, user.ID, idemKey); prev != nil {
+			replayIdempotent(c, prev, idemHash)
+			return
+		}
+	}
+
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var siswa app.Siswa
+	if err := tx.Where("user_id = ?", user.ID).First(&siswa).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not siswa"})
+		return
+	}
+
+	// 🔒 kunci keranjang: 2 checkout paralel tidak bisa memakai isi yang sama
+	var cart []app.CartItem
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Preload("Menu").
+		Where("user_id = ?", user.ID).
+		Order("id ASC").
+		Find(&cart).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load cart"})
+		return
+	}
+	if len(cart) == 0 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cart is empty"})
+		return
+	}
+
+	items := make([]OrderItemPayload, 0, len(cart))
+	for _, it := range cart {
+		items = append(items, OrderItemPayload{MenuID: it.Menu.PublicID, Qty: it.Qty})
+	}
+
+	orders, oerr := takeOrderItems(tx, items)
+	if oerr != nil {
+		tx.Rollback()
+		c.JSON(oerr.status, oerr.body)
+		return
+	}
+
+	method := app.PaymentMethod(p.PaymentMethod)
+	checkout := app.Checkout{UserID: user.ID, PaymentMethod: method}
+	if err := tx.Create(&checkout).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create checkout"})
+		return
+	}
+
+	now := time.Now()
+	for _, so := range orders {
+		if oerr := placeStanOrder(tx, so, placeOrderOpts{
+			siswaID:    siswa.ID,
+			method:     method,
+			pickupSlot: p.PickupSlot,
+			checkoutID: &checkout.ID,
+			now:        now,
+		}); oerr != nil {
+			tx.Rollback()
+			c.JSON(oerr.status, oerr.body)
+			return
+		}
+	}
+
+	// 🎟️ voucher (opsional): 1 voucher = 1 order.
+	// voucher khusus stan → order stan tsb; voucher global → order dengan subtotal terbesar.
+	var voucherInfo interface{} = nil
+	if code := app.NormalizeVoucherCode(p.VoucherCode); code != "" {
+		target := voucherTargetOrder(tx, code, orders)
+		if oerr := redeemOrderVoucher(tx, code, user.ID, target); oerr != nil {
+			tx.Rollback()
+			c.JSON(oerr.status, oerr.body)
+			return
+		}
+		info := target.voucher.(gin.H)
+		info["transaksi_id"] = target.trx.PublicID
+		voucherInfo = info
+	}
+
+	var total float64
+	for _, so := range orders {
+		total += so.total()
+	}
+	total = app.Round2(total)
+
+	if err := tx.Model(&checkout).UpdateColumn("total", total).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create checkout"})
+		return
+	}
+	checkout.Total = total
+
+	// 💳 saldo dipotong SEKALI untuk semua order (1 baris ledger per checkout)
+	var saldo interface{} = nil
+	if method == app.PaymentWallet {
+		after, err := app.DebitCheckout(tx, user.ID, total, checkout.ID, "checkout "+checkout.PublicID)
+		if err != nil {
+			tx.Rollback()
+			if errors.Is(err, app.ErrInsufficientSaldo) {
+				c.JSON(http.StatusPaymentRequired, gin.H{
+					"error": "saldo tidak cukup",
+					"saldo": app.Round2(after),
+					"total": total,
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to debit wallet"})
+			return
+		}
+		saldo = after
+	}
+
+	if err := tx.Where("user_id = ?", user.ID).Delete(&app.CartItem{}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to clear cart"})
+		return
+	}
+
+	stanIDs := make([]uint, 0, len(orders))
+	for _, so := range orders {
+		stanIDs = append(stanIDs, so.stanID)
+	}
+	stans := stansByID(stanIDs)
+
+	outOrders := make([]gin.H, 0, len(orders))
+	for _, so := range orders {
+		stan := stans[so.stanID]
+		outOrders = append(outOrders, gin.H{
+			"transaksi_id": so.trx.PublicID,
+			"stan": gin.H{
+				"id":   stan.PublicID,
+				"name": stan.NamaStan,
+			},
+			"status":         so.trx.Status,
+			"subtotal":       app.Round2(so.subtotal),
+			"total":          so.total(),
+			"payment_status": so.trx.PaymentStatus,
+			"voucher":        so.voucher,
+			"pickup":         app.PickupJSON(so.trx),
+			"short_code":     so.trx.ShortCode(),
+			"qr_payload":     app.OrderQRPayload(so.trx.PublicID, stan.PublicID),
+		})
+	}
+
+	resp := gin.H{
+		"checkout_id":    checkout.PublicID,
+		"total":          total,
+		"payment_method": method,
+		"saldo":          saldo,
+		"voucher":        voucherInfo,
+		"orders":         outOrders,
+	}
+
+	if idemKey != "" {
+		if err := saveIdempotencyKey(tx, user.ID, idemKey, idemHash, http.StatusCreated, resp); err != nil {
+			tx.Rollback()
+			// request paralel dengan key sama sudah commit duluan
+			if prev := findIdempotencyKey(app.DB, user.ID, idemKey); prev != nil {
+				replayIdempotent(c, prev, idemHash)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save idempotency key"})
+			return
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+		return
+	}
+
+	for _, so := range orders {
+		app.PublishOrderEvent(&so.trx, app.EventOrderCreated)
+	}
+
+	c.JSON(http.StatusCreated, resp)
+}
+
+// voucherTargetOrder order yang menerima voucher. Voucher tidak dikenal /
+// stan tidak ada di keranjang → order pertama, RedeemVoucher yang menolak.
+func voucherTargetOrder(tx *gorm.DB, code string, orders []*stanOrder) *stanOrder {
+	var v app.Voucher
+	if err := tx.Where("kode = ?", code).First(&v).Error; err == nil && v.StanID != nil {
+		for _, so := range orders {
+			if so.stanID == *v.StanID {
+				return so
+			}
+		}
+		return orders[0]
+	}
+
+	sorted := append([]*stanOrder(nil), orders...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].subtotal > sorted[j].subtotal })
+	return sorted[0]
+}
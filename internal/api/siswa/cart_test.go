@@ -0,0 +1,208 @@
+package siswa
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+type cartFixture struct {
+	db    *gorm.DB
+	user  *app.User
+	stanA *app.Stan
+	stanB *app.Stan
+}
+
+// newCartFixture keranjang 2 stan: A = 2 × 10.000, B = 1 × 8.000 (total 28.000)
+func newCartFixture(t *testing.T, saldo float64) *cartFixture {
+	t.Helper()
+	db := apptest.OpenDB(t)
+	user, _ := apptest.SeedSiswa(t, db, saldo)
+	stanA := apptest.SeedStan(t, db, "Stan A")
+	stanB := apptest.SeedStan(t, db, "Stan B")
+	nasi := apptest.SeedMenu(t, db, stanA.ID, "Nasi Goreng", 10000)
+	jus := apptest.SeedMenu(t, db, stanB.ID, "Jus Jeruk", 8000)
+
+	for _, it := range []app.CartItem{
+		{UserID: user.ID, MenuID: nasi.ID, Qty: 2},
+		{UserID: user.ID, MenuID: jus.ID, Qty: 1},
+	} {
+		if err := db.Create(&it).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	return &cartFixture{db: db, user: user, stanA: stanA, stanB: stanB}
+}
+
+// closeStan tutup sementara yang sedang berlangsung
+func (f *cartFixture) closeStan(t *testing.T, stanID uint) {
+	t.Helper()
+	now := time.Now().UTC()
+	if err := f.db.Create(&app.StanTutup{
+		StanID:  stanID,
+		Mulai:   now.Add(-time.Hour),
+		Selesai: now.Add(time.Hour),
+		Alasan:  "libur",
+	}).Error; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func (f *cartFixture) count(t *testing.T, model interface{}) int64 {
+	t.Helper()
+	var n int64
+	f.db.Model(model).Count(&n)
+	return n
+}
+
+func (f *cartFixture) saldo(t *testing.T) float64 {
+	t.Helper()
+	var u app.User
+	f.db.First(&u, f.user.ID)
+	return app.Round2(u.Saldo)
+}
+
+func TestCheckoutCartSingleDebit(t *testing.T) {
+	f := newCartFixture(t, 50000)
+
+	w := callHandler(t, SiswaCheckoutCart, f.user, http.MethodPost, CheckoutPayload{PaymentMethod: "wallet"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	body := decodeBody(t, w)
+	if body["total"] != 28000.0 || body["saldo"] != 22000.0 {
+		t.Errorf("total/saldo = %v/%v, want 28000/22000", body["total"], body["saldo"])
+	}
+	if orders := body["orders"].([]interface{}); len(orders) != 2 {
+		t.Fatalf("orders = %d, want 2", len(orders))
+	}
+
+	var checkout app.Checkout
+	if err := f.db.First(&checkout).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	// 1 debit untuk seluruh checkout, tidak ada debit per transaksi
+	var debits []app.WalletTransaction
+	f.db.Where("user_id = ? AND type = ?", f.user.ID, app.WalletDebit).Find(&debits)
+	if len(debits) != 1 {
+		t.Fatalf("debit rows = %d, want 1", len(debits))
+	}
+	d := debits[0]
+	if d.CheckoutID == nil || *d.CheckoutID != checkout.ID || d.TransaksiID != nil || d.Amount != -28000 {
+		t.Errorf("debit = %+v, want checkout %d amount -28000", d, checkout.ID)
+	}
+
+	var trxs []app.Transaksi
+	f.db.Preload("Details").Find(&trxs)
+	var sum float64
+	for _, trx := range trxs {
+		if trx.CheckoutID == nil || *trx.CheckoutID != checkout.ID || trx.PaymentStatus != app.PaymentPaid {
+			t.Errorf("trx %s checkout/payment = %v/%s", trx.PublicID, trx.CheckoutID, trx.PaymentStatus)
+		}
+		sum += trx.Total()
+	}
+	if len(trxs) != 2 || app.Round2(sum) != checkout.Total {
+		t.Errorf("trx = %d sum %v, checkout total %v", len(trxs), sum, checkout.Total)
+	}
+
+	if ledger, _ := app.LedgerSaldo(f.db, f.user.ID); ledger != f.saldo(t) {
+		t.Errorf("ledger %v != saldo %v", ledger, f.saldo(t))
+	}
+	if n := f.count(t, &app.CartItem{}); n != 0 {
+		t.Errorf("cart items = %d, want 0", n)
+	}
+}
+
+func TestCheckoutCartAtomic(t *testing.T) {
+	cases := []struct {
+		name   string
+		saldo  float64
+		setup  func(t *testing.T, f *cartFixture)
+		status int
+	}{
+		{"stan kedua tutup", 50000, func(t *testing.T, f *cartFixture) { f.closeStan(t, f.stanB.ID) }, http.StatusConflict},
+		{"saldo kurang", 20000, nil, http.StatusPaymentRequired},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newCartFixture(t, tc.saldo)
+			if tc.setup != nil {
+				tc.setup(t, f)
+			}
+
+			w := callHandler(t, SiswaCheckoutCart, f.user, http.MethodPost, CheckoutPayload{PaymentMethod: "wallet"})
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.status, w.Body.String())
+			}
+
+			// tidak ada yang tersimpan: order, checkout, ledger, keranjang utuh
+			if n := f.count(t, &app.Transaksi{}); n != 0 {
+				t.Errorf("transaksi = %d, want 0", n)
+			}
+			if n := f.count(t, &app.Checkout{}); n != 0 {
+				t.Errorf("checkout = %d, want 0", n)
+			}
+			if n := f.count(t, &app.CartItem{}); n != 2 {
+				t.Errorf("cart items = %d, want 2", n)
+			}
+			if got := f.saldo(t); got != tc.saldo {
+				t.Errorf("saldo = %v, want %v", got, tc.saldo)
+			}
+		})
+	}
+}
+
+func TestCartCanCheckoutStanClosed(t *testing.T) {
+	f := newCartFixture(t, 50000)
+
+	w := callHandler(t, SiswaGetCart, f.user, http.MethodGet, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	if got := decodeBody(t, w)["can_checkout"]; got != true {
+		t.Fatalf("can_checkout (buka) = %v, want true", got)
+	}
+
+	f.closeStan(t, f.stanB.ID)
+	body := decodeBody(t, callHandler(t, SiswaGetCart, f.user, http.MethodGet, nil))
+	if got := body["can_checkout"]; got != false {
+		t.Errorf("can_checkout (stan tutup) = %v, want false", got)
+	}
+}
+
+func TestCheckoutIdempotency(t *testing.T) {
+	f := newCartFixture(t, 50000)
+	key := []string{idempotencyHeader, "checkout-1"}
+
+	first := callHandler(t, SiswaCheckoutCart, f.user, http.MethodPost, CheckoutPayload{PaymentMethod: "wallet"}, key...)
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: status = %d, body %s", first.Code, first.Body.String())
+	}
+
+	// retry setelah keranjang kosong tetap replay (isi keranjang tidak di-hash)
+	again := callHandler(t, SiswaCheckoutCart, f.user, http.MethodPost, CheckoutPayload{PaymentMethod: "wallet"}, key...)
+	if again.Code != http.StatusCreated || again.Header().Get("Idempotent-Replayed") != "true" {
+		t.Fatalf("replay: status = %d replayed %q", again.Code, again.Header().Get("Idempotent-Replayed"))
+	}
+	if a, b := decodeBody(t, first)["checkout_id"], decodeBody(t, again)["checkout_id"]; a != b {
+		t.Errorf("replay checkout_id = %v, want %v", b, a)
+	}
+
+	conflict := callHandler(t, SiswaCheckoutCart, f.user, http.MethodPost, CheckoutPayload{PaymentMethod: "cash"}, key...)
+	if conflict.Code != http.StatusConflict {
+		t.Errorf("beda payment_method: status = %d, want 409", conflict.Code)
+	}
+
+	if n := f.count(t, &app.Checkout{}); n != 1 {
+		t.Errorf("checkout = %d, want 1", n)
+	}
+	if got := f.saldo(t); got != 22000 {
+		t.Errorf("saldo = %v, want 22000", got)
+	}
+}
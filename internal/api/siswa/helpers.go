@@ -1,6 +1,13 @@
 package siswa
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
 	"github.com/samudsamudra/UKK_kantin/internal/app"
 )
 
@@ -23,3 +30,149 @@ func getStanNameByID(id uint) string {
 		Scan(&name)
 	return name
 }
+
+// remainingStock returns menus.stok terkini (nil = unlimited)
+func remainingStock(menuID uint) *int {
+	var m app.Menu
+	if err := app.DB.Select("stok").Where("id = ?", menuID).First(&m).Error; err != nil {
+		return nil
+	}
+	return m.Stok
+}
+
+// detailJSON 1 baris item: harga normal / diskon / harga akhir (dari snapshot)
+func detailJSON(d app.DetailTransaksi) gin.H {
+	var diskon gin.H
+	if d.DiskonAmount() > 0 {
+		diskon = gin.H{
+			"diskon_id": d.DiskonPublicID,
+			"nama":      d.DiskonNama,
+			"persen":    d.DiskonPersen,
+			"potongan":  app.Round2(d.DiskonAmount()),
+		}
+	}
+
+	return gin.H{
+		"menu":         d.MenuName(),
+		"qty":          d.Qty,
+		"harga_normal": app.Round2(d.OriginalPrice()),
+		"harga_beli":   app.Round2(d.HargaBeli), // harga akhir per item
+		"diskon":       diskon,
+		"subtotal":     app.Round2(d.Subtotal()),
+	}
+}
+
+// diskonPreviewJSON info diskon di preview harga menu (nil jika tidak ada)
+func diskonPreviewJSON(pl app.PricedLine) interface{} {
+	if len(pl.Applied) == 0 {
+		return nil
+	}
+
+	applied := make([]gin.H, 0, len(pl.Applied))
+	for _, a := range pl.Applied {
+		applied = append(applied, gin.H{
+			"diskon_id": a.Diskon.PublicID,
+			"nama":      a.Diskon.Nama,
+			"tipe":      a.Diskon.Tipe,
+			"label":     a.Diskon.Label(),
+			"potongan":  a.Potongan,
+		})
+	}
+
+	return gin.H{
+		"nama":       pl.DiskonNames(),
+		"persentase": pl.Persen(),
+		"potongan":   pl.Potongan(),
+		"applied":    applied,
+	}
+}
+
+// voucherErrorStatus status HTTP untuk error validasi voucher
+func voucherErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, app.ErrVoucherNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, app.ErrVoucherQuotaUsedUp),
+		errors.Is(err, app.ErrVoucherUserLimit):
+		return http.StatusConflict, true
+	case errors.Is(err, app.ErrVoucherInactive),
+		errors.Is(err, app.ErrVoucherNotStarted),
+		errors.Is(err, app.ErrVoucherExpired),
+		errors.Is(err, app.ErrVoucherWrongStan),
+		errors.Is(err, app.ErrVoucherMinBelanja),
+		errors.Is(err, app.ErrVoucherNoPotongan):
+		return http.StatusUnprocessableEntity, true
+	}
+	return 0, false
+}
+
+// pickupSlotErrorStatus status HTTP untuk error validasi slot pengambilan
+func pickupSlotErrorStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, app.ErrPickupSlotNotFound):
+		return http.StatusNotFound, true
+	case errors.Is(err, app.ErrPickupSlotFull):
+		return http.StatusConflict, true
+	case errors.Is(err, app.ErrPickupSlotInactive),
+		errors.Is(err, app.ErrPickupSlotClosed):
+		return http.StatusUnprocessableEntity, true
+	}
+	return 0, false
+}
+
+// nullIfEmpty "" → null di JSON
+func nullIfEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+// splitQueryList ?x=a,b&x=c → [a b c]
+func splitQueryList(values []string) []string {
+	out := []string{}
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			if p := strings.TrimSpace(part); p != "" {
+				out = append(out, p)
+			}
+		}
+	}
+	return out
+}
+
+// kategoriJSON kategori menu (null jika tanpa kategori)
+func kategoriJSON(k *app.MenuKategori) interface{} {
+	if k == nil {
+		return nil
+	}
+	return gin.H{"id": k.PublicID, "nama": k.Nama}
+}
+
+// stansByID map stan.id → stan (1 query)
+func stansByID(ids []uint) map[uint]app.Stan {
+	out := map[uint]app.Stan{}
+	if len(ids) == 0 {
+		return out
+	}
+	var stans []app.Stan
+	app.DB.Where("id IN ?", ids).Find(&stans)
+	for _, s := range stans {
+		out[s.ID] = s
+	}
+	return out
+}
+
+// parsePriceQuery ?key=angka >= 0 (kosong → nil). false = response error sudah dikirim
+func parsePriceQuery(c *gin.Context, key string) (*float64, bool) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil, true
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid " + key})
+		return nil, false
+	}
+	return &v, true
+}
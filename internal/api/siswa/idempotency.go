@@ -0,0 +1,89 @@
+package siswa
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// idempotencyHeader = header alternatif untuk idempotency_key di body
+const idempotencyHeader = "Idempotency-Key"
+
+// resolveIdempotencyKey: header diutamakan, fallback ke idempotency_key di body.
+func resolveIdempotencyKey(header string, fromBody *string) string {
+	if k := strings.TrimSpace(header); k != "" {
+		return k
+	}
+	if fromBody != nil {
+		return strings.TrimSpace(*fromBody)
+	}
+	return ""
+}
+
+// hashOrderPayload menghasilkan sidik jari body order (tanpa idempotency key),
+// dipakai untuk mendeteksi key sama tapi isi berbeda.
+func hashOrderPayload(p CreateOrderPayload) string {
+	p.IdempotencyKey = nil
+	b, _ := json.Marshal(p)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hashCheckoutPayload sidik jari body checkout keranjang. Isi keranjang tidak
+// ikut di-hash: setelah checkout keranjang kosong, retry tetap harus replay.
+func hashCheckoutPayload(p CheckoutPayload) string {
+	p.IdempotencyKey = nil
+	b, _ := json.Marshal(gin.H{"checkout": p})
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// findIdempotencyKey mencari key milik user. nil jika belum pernah dipakai.
+func findIdempotencyKey(db *gorm.DB, userID uint, key string) *app.IdempotencyKey {
+	var k app.IdempotencyKey
+	if err := db.
+		Where("user_id = ? AND `key` = ?", userID, key).
+		First(&k).Error; err != nil {
+		return nil
+	}
+	return &k
+}
+
+// saveIdempotencyKey menyimpan response asli di dalam tx order.
+// Unique index (user_id, key) menjamin request paralel dengan key sama
+// hanya satu yang bisa commit.
+func saveIdempotencyKey(tx *gorm.DB, userID uint, key, hash string, status int, body interface{}) error {
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	return tx.Create(&app.IdempotencyKey{
+		UserID:         userID,
+		Key:            key,
+		RequestHash:    hash,
+		ResponseStatus: status,
+		ResponseBody:   string(b),
+		CreatedAt:      time.Now(),
+	}).Error
+}
+
+// replayIdempotent mengirim ulang response asli,
+// atau 409 jika key dipakai ulang dengan body berbeda.
+func replayIdempotent(c *gin.Context, prev *app.IdempotencyKey, hash string) {
+	if prev.RequestHash != hash {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": "idempotency key already used with a different payload",
+		})
+		return
+	}
+	c.Header("Idempotent-Replayed", "true")
+	c.Data(prev.ResponseStatus, "application/json; charset=utf-8", []byte(prev.ResponseBody))
+}
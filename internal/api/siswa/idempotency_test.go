@@ -0,0 +1,81 @@
+package siswa
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+func TestCreateOrderIdempotency(t *testing.T) {
+	db := apptest.OpenDB(t)
+	user, _ := apptest.SeedSiswa(t, db, 100000)
+	stan := apptest.SeedStan(t, db, "Kantin Idem")
+	nasi := apptest.SeedMenu(t, db, stan.ID, "Nasi Goreng", 10000)
+	teh := apptest.SeedMenu(t, db, stan.ID, "Es Teh", 5000)
+
+	order := func(menuID string, qty int) CreateOrderPayload {
+		return CreateOrderPayload{
+			Items:         []OrderItemPayload{{MenuID: menuID, Qty: qty}},
+			PaymentMethod: "wallet",
+		}
+	}
+	bodyKey := "body-key-1"
+	withBodyKey := order(nasi.PublicID, 1)
+	withBodyKey.IdempotencyKey = &bodyKey
+
+	first := callHandler(t, SiswaCreateOrder, user, http.MethodPost, order(nasi.PublicID, 2), idempotencyHeader, "key-1")
+	if first.Code != http.StatusCreated {
+		t.Fatalf("first: status = %d, body %s", first.Code, first.Body.String())
+	}
+	firstTrx := decodeBody(t, first)["transaksi_id"]
+
+	cases := []struct {
+		name     string
+		payload  CreateOrderPayload
+		headers  []string
+		status   int
+		replayed bool
+	}{
+		{"key sama + body sama → response asli", order(nasi.PublicID, 2), []string{idempotencyHeader, "key-1"}, http.StatusCreated, true},
+		{"key sama + qty beda → 409", order(nasi.PublicID, 3), []string{idempotencyHeader, "key-1"}, http.StatusConflict, false},
+		{"key sama + menu beda → 409", order(teh.PublicID, 2), []string{idempotencyHeader, "key-1"}, http.StatusConflict, false},
+		{"key di body", withBodyKey, nil, http.StatusCreated, false},
+		{"key di body diulang", withBodyKey, nil, http.StatusCreated, true},
+		{"key body = header → replay", withBodyKey, []string{idempotencyHeader, bodyKey}, http.StatusCreated, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			w := callHandler(t, SiswaCreateOrder, user, http.MethodPost, tc.payload, tc.headers...)
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.status, w.Body.String())
+			}
+			if got := w.Header().Get("Idempotent-Replayed") == "true"; got != tc.replayed {
+				t.Errorf("replayed = %v, want %v", got, tc.replayed)
+			}
+			if tc.replayed && tc.payload.IdempotencyKey == nil {
+				if got := decodeBody(t, w)["transaksi_id"]; got != firstTrx {
+					t.Errorf("replay transaksi_id = %v, want %v", got, firstTrx)
+				}
+			}
+		})
+	}
+
+	// hanya 2 order nyata (key-1 & body-key-1), saldo dipotong 2× saja
+	var n int64
+	db.Model(&app.Transaksi{}).Count(&n)
+	if n != 2 {
+		t.Errorf("transaksi = %d, want 2", n)
+	}
+	var debits int64
+	db.Model(&app.WalletTransaction{}).Where("type = ?", app.WalletDebit).Count(&debits)
+	if debits != 2 {
+		t.Errorf("debit rows = %d, want 2", debits)
+	}
+	var u app.User
+	db.First(&u, user.ID)
+	if u.Saldo != 70000 {
+		t.Errorf("saldo = %v, want 70000", u.Saldo)
+	}
+}
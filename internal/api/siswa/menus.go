@@ -2,6 +2,8 @@ package siswa
 
 import (
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
@@ -17,51 +19,174 @@ import (
 
 // GET /api/siswa/menus
 // optional: ?stan_id=<stan_public_id>
+// optional: ?hide_sold_out=true (sembunyikan menu habis / tidak tersedia)
+// optional: ?kategori_id=<kategori_public_id>
+// optional: ?tag=pedas,vegetarian (boleh berulang; menu harus punya SEMUA tag)
+// optional: ?exclude_alergen=kacang,susu (buang menu yang mengandung alergen tsb)
+// optional: ?q=ayam geprek (cari di nama & deskripsi)
+// optional: ?jenis=makanan|minuman
+// optional: ?min_price=5000&max_price=15000 (harga normal, sebelum diskon)
+// optional: ?sort=newest|price_asc|price_desc|popular|relevance
+//
+//	default: relevance jika ada ?q=, selain itu newest
+//	popular = qty terjual 30 hari terakhir
+//
+// optional: ?page=1&limit=20
 func SiswaListMenus(c *gin.Context) {
-	stanPub := c.Query("stan_id")
 	db := app.DB
 
-	var menus []app.Menu
+	// =========================
+	// FILTER
+	// =========================
+	q := db.Model(&app.Menu{})
 
-	if stanPub != "" {
+	if stanPub := c.Query("stan_id"); stanPub != "" {
 		var stan app.Stan
 		if err := db.Where("public_id = ?", stanPub).First(&stan).Error; err != nil {
 			c.JSON(http.StatusBadRequest, gin.H{"error": "stan not found"})
 			return
 		}
+		q = q.Where("menus.stan_id = ?", stan.ID)
+	}
 
-		if err := db.
-			Where("stan_id = ?", stan.ID).
-			Find(&menus).Error; err != nil {
+	if c.Query("hide_sold_out") == "true" {
+		q = q.Where("menus.is_available = ? AND (menus.stok IS NULL OR menus.stok > 0)", true)
+	}
 
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch menus"})
+	if katPub := c.Query("kategori_id"); katPub != "" {
+		var kat app.MenuKategori
+		if err := db.Where("public_id = ?", katPub).First(&kat).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "kategori not found"})
 			return
 		}
-	} else {
-		if err := db.Find(&menus).Error; err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch menus"})
+		q = q.Where("menus.kategori_id = ?", kat.ID)
+	}
+
+	if raw := splitQueryList(c.QueryArray("tag")); len(raw) > 0 {
+		tags, err := app.NormalizeTags(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q = app.FilterMenusByTags(q, tags)
+	}
+
+	if raw := splitQueryList(c.QueryArray("exclude_alergen")); len(raw) > 0 {
+		exclude := make([]app.Alergen, 0, len(raw))
+		for _, r := range raw {
+			a := app.Alergen(strings.ToLower(r))
+			if !app.IsValidAlergen(a) {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alergen, use kacang|susu|seafood|gluten"})
+				return
+			}
+			exclude = append(exclude, a)
+		}
+		q = app.ExcludeMenusWithAlergen(q, exclude)
+	}
+
+	if jenis := strings.ToLower(strings.TrimSpace(c.Query("jenis"))); jenis != "" {
+		if app.MenuJenis(jenis) != app.JenisMakanan && app.MenuJenis(jenis) != app.JenisMinuman {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid jenis, use makanan|minuman"})
 			return
 		}
+		q = q.Where("menus.jenis = ?", jenis)
+	}
+
+	minPrice, okMin := parsePriceQuery(c, "min_price")
+	maxPrice, okMax := parsePriceQuery(c, "max_price")
+	if !okMin || !okMax {
+		return
+	}
+	if minPrice != nil && maxPrice != nil && *minPrice > *maxPrice {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "min_price must not be greater than max_price"})
+		return
+	}
+	if minPrice != nil {
+		q = q.Where("menus.harga >= ?", *minPrice)
+	}
+	if maxPrice != nil {
+		q = q.Where("menus.harga <= ?", *maxPrice)
+	}
+
+	search := app.ParseMenuSearch(c.Query("q"))
+	if !search.Empty() {
+		q = search.Apply(q)
+	}
+
+	// =========================
+	// SORT & PAGINATION
+	// =========================
+	sortBy := c.Query("sort")
+	if sortBy == "" {
+		sortBy = "newest"
+		if !search.Empty() {
+			sortBy = "relevance"
+		}
+	}
+
+	pg := app.ParsePagination(c)
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count menus"})
+		return
 	}
 
+	// tie-breaker stabil untuk pagination
+	const tieBreak = "menus.created_at DESC, menus.id DESC"
+
+	list := q.Session(&gorm.Session{}).Select("menus.*")
+	switch sortBy {
+	case "newest":
+		list = list.Order(tieBreak)
+	case "price_asc":
+		list = list.Order("menus.harga ASC, " + tieBreak)
+	case "price_desc":
+		list = list.Order("menus.harga DESC, " + tieBreak)
+	case "popular":
+		list = app.JoinMenuPopularity(list, time.Now().Add(-app.PopularWindow)).
+			Order("COALESCE(pop.sold, 0) DESC, " + tieBreak)
+	case "relevance":
+		list = search.OrderByRelevance(list, tieBreak)
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid sort, use newest|price_asc|price_desc|popular|relevance"})
+		return
+	}
 
+	var menus []app.Menu
+	if err := list.
+		Preload("Kategori").
+		Preload("Tags").
+		Offset(pg.Offset()).
+		Limit(pg.Limit).
+		Find(&menus).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch menus"})
+		return
+	}
+
+	// =========================
+	// BULK: STAN & DISKON (1 query masing-masing)
+	// =========================
+	stanIDs := make([]uint, 0, len(menus))
+	for _, m := range menus {
+		stanIDs = append(stanIDs, m.StanID)
+	}
+	stans := stansByID(stanIDs)
+
+	prices := app.NewDiscountCache(db, time.Now())
+	if err := prices.Load(stanIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load discounts"})
+		return
+	}
 
 	out := make([]gin.H, 0, len(menus))
 	for _, m := range menus {
-		stanID := getStanPublicIDByID(m.StanID)
-		stanName := getStanNameByID(m.StanID)
-
-		price := app.Round2(m.Harga)
-		priceFinal := price
-
-		var diskonInfo interface{} = nil
-		if diskon := app.GetActiveDiscountByStan(m.StanID); diskon != nil {
-			priceFinal = app.ApplyDiscount(price, diskon.Persentase)
-			diskonInfo = gin.H{
-				"nama":       diskon.Nama,
-				"persentase": diskon.Persentase,
-			}
-		}
+		stan := stans[m.StanID]
+
+		pl := prices.Preview(m)
+		price := pl.HargaNormal
+		priceFinal := pl.HargaAkhir
+		diskonInfo := diskonPreviewJSON(pl)
 
 		out = append(out, gin.H{
 			"id":          m.PublicID,
@@ -73,9 +198,20 @@ func SiswaListMenus(c *gin.Context) {
 			"price_final": priceFinal,
 			"diskon":      diskonInfo,
 
+			"stok":      m.Stok,
+			"available": !m.SoldOut(),
+			"sold_out":  m.SoldOut(),
+
+			"image_url":     nullIfEmpty(m.ImageURL()),
+			"thumbnail_url": nullIfEmpty(m.ThumbURL()),
+
+			"kategori": kategoriJSON(m.Kategori),
+			"tags":     m.TagNames(),
+			"alergen":  m.AlergenList(),
+
 			"stan": gin.H{
-				"id":   stanID,
-				"name": stanName,
+				"id":   stan.PublicID,
+				"name": stan.NamaStan,
 			},
 
 			"created_at":       m.CreatedAt,
@@ -85,7 +221,11 @@ func SiswaListMenus(c *gin.Context) {
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"menus": out})
+	c.JSON(http.StatusOK, gin.H{
+		"menus":      out,
+		"pagination": pg.Meta(total),
+		"sort":       sortBy,
+	})
 }
 
 //
@@ -104,6 +244,8 @@ func SiswaGetMenu(c *gin.Context) {
 
 	var m app.Menu
 	if err := app.DB.
+		Preload("Kategori").
+		Preload("Tags").
 		Where("public_id = ?", pub).
 		First(&m).Error; err != nil {
 
@@ -118,23 +260,11 @@ func SiswaGetMenu(c *gin.Context) {
 	stanID := getStanPublicIDByID(m.StanID)
 	stanName := getStanNameByID(m.StanID)
 
-	// 🔑 diskon aktif
-	// diskon := app.GetActiveDiscount()
-
-	// 🔑 diskon aktif per-stan
-	diskon := app.GetActiveDiscountByStan(m.StanID)
-
-	price := app.Round2(m.Harga)
-	priceFinal := price
-
-	var diskonInfo interface{} = nil
-	if diskon != nil {
-		priceFinal = app.ApplyDiscount(price, diskon.Persentase)
-		diskonInfo = gin.H{
-			"nama":       diskon.Nama,
-			"persentase": diskon.Persentase,
-		}
-	}
+	// 🔑 diskon aktif per-stan (engine yang sama dengan checkout)
+	pl := app.NewDiscountCache(app.DB, time.Now()).Preview(m)
+	price := pl.HargaNormal
+	priceFinal := pl.HargaAkhir
+	diskonInfo := diskonPreviewJSON(pl)
 
 	c.JSON(http.StatusOK, gin.H{
 		"id":          m.PublicID,
@@ -146,6 +276,17 @@ func SiswaGetMenu(c *gin.Context) {
 		"price_final": priceFinal,
 		"diskon":      diskonInfo,
 
+		"stok":      m.Stok,
+		"available": !m.SoldOut(),
+		"sold_out":  m.SoldOut(),
+
+		"image_url":     nullIfEmpty(m.ImageURL()),
+		"thumbnail_url": nullIfEmpty(m.ThumbURL()),
+
+		"kategori": kategoriJSON(m.Kategori),
+		"tags":     m.TagNames(),
+		"alergen":  m.AlergenList(),
+
 		"stan": gin.H{
 			"id":   stanID,
 			"name": stanName,
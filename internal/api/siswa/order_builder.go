@@ -0,0 +1,222 @@
+package siswa
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// =========================
+// ORDER BUILDER (dipakai POST /orders & checkout keranjang)
+// =========================
+//
+// Semua langkah WAJIB di dalam 1 tx. Error dikembalikan sebagai *orderError
+// (status HTTP + body), pemanggil cukup rollback lalu kirim apa adanya.
+
+type orderError struct {
+	status int
+	body   gin.H
+}
+
+func (e *orderError) Error() string {
+	msg, _ := e.body["error"].(string)
+	return msg
+}
+
+func newOrderError(status int, msg string) *orderError {
+	return &orderError{status: status, body: gin.H{"error": msg}}
+}
+
+// stanOrder bagian order untuk 1 stan (1 Transaksi)
+type stanOrder struct {
+	stanID   uint
+	lines    []app.PriceLine
+	trx      app.Transaksi
+	subtotal float64 // total item setelah diskon otomatis, sebelum voucher
+	voucher  interface{}
+}
+
+// takeOrderItems ambil menu + kurangi stok atomik, dikelompokkan per stan
+// (urutan stan = urutan kemunculan item pertama).
+func takeOrderItems(tx *gorm.DB, items []OrderItemPayload) ([]*stanOrder, *orderError) {
+	orders := []*stanOrder{}
+	byStan := map[uint]*stanOrder{}
+
+	for _, it := range items {
+		var menu app.Menu
+		if err := tx.
+			Where("public_id = ?", it.MenuID).
+			First(&menu).Error; err != nil {
+			return nil, newOrderError(http.StatusBadRequest, "menu not found")
+		}
+
+		// 📦 stok: kurangi atomik, tolak jika habis / tidak tersedia
+		if err := app.DecrementStock(tx, &menu, it.Qty); err != nil {
+			switch {
+			case errors.Is(err, app.ErrMenuUnavailable):
+				return nil, &orderError{http.StatusConflict, gin.H{
+					"error":   "menu not available",
+					"menu_id": menu.PublicID,
+				}}
+			case errors.Is(err, app.ErrStockNotEnough):
+				return nil, &orderError{http.StatusConflict, gin.H{
+					"error":   "stok tidak cukup",
+					"menu_id": menu.PublicID,
+					"menu":    menu.NamaMakanan,
+					"stok":    remainingStock(menu.ID),
+				}}
+			default:
+				return nil, newOrderError(http.StatusInternalServerError, "failed to update stock")
+			}
+		}
+
+		so, ok := byStan[menu.StanID]
+		if !ok {
+			so = &stanOrder{stanID: menu.StanID}
+			byStan[menu.StanID] = so
+			orders = append(orders, so)
+		}
+		so.lines = append(so.lines, app.PriceLine{Menu: menu, Qty: it.Qty})
+	}
+
+	return orders, nil
+}
+
+type placeOrderOpts struct {
+	siswaID    uint
+	method     app.PaymentMethod
+	pickupSlot string // public id slot, "" = ambil langsung
+	checkoutID *uint
+	now        time.Time
+}
+
+// placeStanOrder slot pengambilan → cek stan buka → harga final → simpan
+// Transaksi + detail. Hasil di so.trx & so.subtotal.
+func placeStanOrder(tx *gorm.DB, so *stanOrder, o placeOrderOpts) *orderError {
+	// ⏰ pre-order (opsional): ambil di slot istirahat hari ini
+	var slot *app.PickupSlot
+	checkAt := o.now
+	if strings.TrimSpace(o.pickupSlot) != "" {
+		s, pickupAt, err := app.ReservePickupSlot(tx, o.pickupSlot, so.stanID, o.now)
+		if err != nil {
+			if status, ok := pickupSlotErrorStatus(err); ok {
+				return &orderError{status, gin.H{
+					"error":       err.Error(),
+					"pickup_slot": o.pickupSlot,
+					"stan_id":     getStanPublicIDByID(so.stanID),
+				}}
+			}
+			return newOrderError(http.StatusInternalServerError, "failed to reserve pickup slot")
+		}
+		slot = s
+		checkAt = pickupAt
+	}
+
+	// 🕒 stan harus buka saat order (atau saat pengambilan untuk pre-order)
+	status, err := app.StanStatusAt(tx, so.stanID, checkAt)
+	if err != nil {
+		return newOrderError(http.StatusInternalServerError, "failed to check jam buka")
+	}
+	if !status.Open {
+		return &orderError{http.StatusConflict, gin.H{
+			"error":   "stan sedang tutup",
+			"reason":  status.Reason,
+			"stan_id": getStanPublicIDByID(so.stanID),
+		}}
+	}
+
+	// 💰 harga final: engine diskon yang sama dengan preview menu
+	discounts, err := app.LoadActiveDiscounts(tx, so.stanID, o.now)
+	if err != nil {
+		return newOrderError(http.StatusInternalServerError, "failed to load discounts")
+	}
+
+	details := make([]app.DetailTransaksi, 0, len(so.lines))
+	for _, pl := range app.PriceLines(discounts, so.lines, o.now) {
+		so.subtotal += float64(pl.Qty) * pl.HargaAkhir
+
+		d := app.DetailTransaksi{
+			MenuID:    pl.Menu.ID,
+			Qty:       pl.Qty,
+			HargaBeli: pl.HargaAkhir, // 🔥 harga sudah diskon
+			CreatedAt: o.now,
+
+			// 📸 snapshot: riwayat tidak berubah walau menu / diskon diedit
+			NamaMenu:    pl.Menu.NamaMakanan,
+			HargaNormal: pl.HargaNormal,
+		}
+		if primary := pl.Primary(); primary != nil {
+			d.DiskonID = &primary.ID
+			d.DiskonPublicID = primary.PublicID
+			d.DiskonNama = pl.DiskonNames()
+			d.DiskonPersen = pl.Persen()
+		}
+		details = append(details, d)
+	}
+
+	payStatus := app.PaymentPending
+	if o.method == app.PaymentWallet {
+		payStatus = app.PaymentPaid
+	}
+
+	so.trx = app.Transaksi{
+		PublicID:      uuid.NewString(),
+		StanID:        so.stanID,
+		SiswaID:       o.siswaID,
+		Status:        app.StatusBelumDikonfirm,
+		PaymentMethod: o.method,
+		PaymentStatus: payStatus,
+		CheckoutID:    o.checkoutID,
+	}
+	if slot != nil {
+		pickupAt := checkAt
+		so.trx.PickupSlotID = &slot.ID
+		so.trx.PickupSlotNama = slot.Nama
+		so.trx.PickupAt = &pickupAt
+	}
+
+	if err := tx.Create(&so.trx).Error; err != nil {
+		return newOrderError(http.StatusInternalServerError, "failed to create transaksi")
+	}
+
+	for i := range details {
+		details[i].TransaksiID = so.trx.ID
+		if err := tx.Create(&details[i]).Error; err != nil {
+			return newOrderError(http.StatusInternalServerError, "failed to create detail")
+		}
+	}
+	so.trx.Details = details
+
+	return nil
+}
+
+// redeemOrderVoucher validasi + catat pemakaian voucher untuk 1 order stan
+func redeemOrderVoucher(tx *gorm.DB, code string, userID uint, so *stanOrder) *orderError {
+	v, err := app.RedeemVoucher(tx, code, userID, &so.trx, so.subtotal)
+	if err != nil {
+		if status, ok := voucherErrorStatus(err); ok {
+			return &orderError{status, gin.H{"error": err.Error(), "voucher_code": code}}
+		}
+		return newOrderError(http.StatusInternalServerError, "failed to redeem voucher")
+	}
+
+	so.voucher = gin.H{
+		"kode":     v.Kode,
+		"nama":     v.Nama,
+		"potongan": so.trx.VoucherPotongan,
+		"subtotal": app.Round2(so.subtotal),
+	}
+	return nil
+}
+
+// total yang dibayar untuk order ini (setelah voucher)
+func (so *stanOrder) total() float64 {
+	return app.Round2(so.subtotal - so.trx.VoucherPotongan)
+}
@@ -2,11 +2,12 @@ package siswa
 
 import (
 	// "log"
+	"errors"
 	"net/http"
+	"sort"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"gorm.io/gorm"
 
 	"github.com/samudsamudra/UKK_kantin/internal/app"
@@ -18,6 +19,12 @@ import (
 // =========================
 //
 
+// GET /api/siswa/orders
+// optional:
+//   ?month=YYYY-MM            (bulan menurut Asia/Jakarta)
+//   ?status=dimasak,diantar   (boleh berulang)
+//   ?stan_id=<stan_public_id>
+//   ?page=1&limit=20
 func SiswaOrdersByMonth(c *gin.Context) {
 	user, ok := getUserFromContext(c)
 	if !ok {
@@ -33,11 +40,55 @@ func SiswaOrdersByMonth(c *gin.Context) {
 		return
 	}
 
+	// =========================
+	// FILTER
+	// =========================
+	q := app.DB.Model(&app.Transaksi{}).Where("transaksis.siswa_id = ?", siswa.ID)
+
+	if month := c.Query("month"); month != "" {
+		start, end, err := app.ParseMonthJakarta(month)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		q = q.Where("transaksis.created_at >= ? AND transaksis.created_at < ?", start, end)
+	}
+
+	statuses, err := app.ParseStatusFilter(c.QueryArray("status"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(statuses) > 0 {
+		q = q.Where("transaksis.status IN ?", statuses)
+	}
+
+	if stanPub := c.Query("stan_id"); stanPub != "" {
+		var stan app.Stan
+		if err := app.DB.Where("public_id = ?", stanPub).First(&stan).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stan not found"})
+			return
+		}
+		q = q.Where("transaksis.stan_id = ?", stan.ID)
+	}
+
+	// =========================
+	// COUNT + PAGE
+	// =========================
+	pg := app.ParsePagination(c)
+
+	var total int64
+	if err := q.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count orders"})
+		return
+	}
+
 	var trxs []app.Transaksi
-	err := app.DB.
+	err = q.Session(&gorm.Session{}).
 		Preload("Details.Menu").
-		Where("siswa_id = ?", siswa.ID).
-		Order("created_at DESC").
+		Order("transaksis.created_at DESC, transaksis.id DESC").
+		Offset(pg.Offset()).
+		Limit(pg.Limit).
 		Find(&trxs).Error
 
 	if err != nil && err != gorm.ErrRecordNotFound {
@@ -45,21 +96,71 @@ func SiswaOrdersByMonth(c *gin.Context) {
 		return
 	}
 
+	// =========================
+	// SUMMARY PER BULAN (WIB)
+	// =========================
+	// jumlah order & pengeluaran per bulan, order batal tidak dihitung.
+	// dikelompokkan di Go (bukan SQL) supaya tidak tergantung timezone koneksi DB.
+	type trxRow struct {
+		ID        uint
+		CreatedAt time.Time
+		Total     float64
+	}
+	var rows []trxRow
+	if err := q.Session(&gorm.Session{}).
+		Select("transaksis.id, transaksis.created_at, GREATEST(COALESCE(SUM(detail_transaksis.qty * detail_transaksis.harga_beli), 0) - transaksis.voucher_potongan, 0) AS total").
+		Joins("LEFT JOIN detail_transaksis ON detail_transaksis.transaksi_id = transaksis.id").
+		Where("transaksis.status NOT IN ?", app.CancelledStatuses).
+		Group("transaksis.id, transaksis.created_at, transaksis.voucher_potongan").
+		Scan(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to summarize orders"})
+		return
+	}
+
+	type monthSum struct {
+		count int
+		spend float64
+	}
+	byMonth := map[string]*monthSum{}
+	monthKeys := []string{}
+	for _, r := range rows {
+		key := r.CreatedAt.In(app.JakartaLoc()).Format("2006-01")
+		ms, ok := byMonth[key]
+		if !ok {
+			ms = &monthSum{}
+			byMonth[key] = ms
+			monthKeys = append(monthKeys, key)
+		}
+		ms.count++
+		ms.spend += r.Total
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(monthKeys)))
+
+	summary := make([]gin.H, 0, len(monthKeys))
+	for _, k := range monthKeys {
+		summary = append(summary, gin.H{
+			"month":       k,
+			"order_count": byMonth[k].count,
+			"total_spend": app.Round2(byMonth[k].spend),
+		})
+	}
+
+	stanIDs := make([]uint, 0, len(trxs))
+	for _, t := range trxs {
+		stanIDs = append(stanIDs, t.StanID)
+	}
+	stanPubIDs := app.StanPublicIDs(stanIDs)
+
 	out := make([]gin.H, 0, len(trxs))
 	for _, t := range trxs {
-		var total float64
+		var total, normal float64
 		items := make([]gin.H, 0, len(t.Details))
 
 		for _, d := range t.Details {
-			sub := float64(d.Qty) * d.HargaBeli
-			total += sub
-
-			items = append(items, gin.H{
-				"menu":       d.Menu.NamaMakanan,
-				"qty":        d.Qty,
-				"harga_beli": app.Round2(d.HargaBeli),
-				"subtotal":   app.Round2(sub),
-			})
+			total += d.Subtotal()
+			normal += d.GrossSubtotal()
+
+			items = append(items, detailJSON(d))
 		}
 
 		out = append(out, gin.H{
@@ -67,12 +168,31 @@ func SiswaOrdersByMonth(c *gin.Context) {
 			"tanggal":      t.CreatedAt,
 			"tanggal_real": app.FormatTimeHuman(t.CreatedAt),
 			"status":       t.Status,
-			"total":        app.Round2(total),
+			"total":        app.Round2(t.Total()),
+			"total_normal": app.Round2(normal),
+			"total_diskon": app.Round2(normal - total),
 			"items":        items,
+
+			"voucher_kode":     t.VoucherKode,
+			"voucher_potongan": app.Round2(t.VoucherPotongan),
+
+			"pickup": app.PickupJSON(t),
+
+			"payment_method": t.PaymentMethod,
+			"payment_status": t.PaymentStatus,
+			"cancel_reason":  t.CancelReason,
+
+			// PENGAMBILAN: tampilkan sebagai QR, discan staf stan
+			"short_code": t.ShortCode(),
+			"qr_payload": app.OrderQRPayload(t.PublicID, stanPubIDs[t.StanID]),
 		})
 	}
 
-	c.JSON(http.StatusOK, gin.H{"orders": out})
+	c.JSON(http.StatusOK, gin.H{
+		"orders":     out,
+		"pagination": pg.Meta(total),
+		"summary":    summary,
+	})
 }
 
 // =========================
@@ -91,6 +211,21 @@ func SiswaCreateOrder(c *gin.Context) {
 		return
 	}
 
+	// 🔁 idempotency: request ulang dengan key sama → response asli
+	idemKey := resolveIdempotencyKey(c.GetHeader(idempotencyHeader), p.IdempotencyKey)
+	var idemHash string
+	if idemKey != "" {
+		if len(idemKey) > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "idempotency key too long"})
+			return
+		}
+		idemHash = hashOrderPayload(p)
+		if prev := findIdempotencyKey(app.DB, user.ID, idemKey); prev != nil {
+			replayIdempotent(c, prev, idemHash)
+			return
+		}
+	}
+
 	tx := app.DB.Begin()
 	defer func() {
 		if r := recover(); r != nil {
@@ -108,71 +243,175 @@ func SiswaCreateOrder(c *gin.Context) {
 		return
 	}
 
+	orders, oerr := takeOrderItems(tx, p.Items)
+	if oerr != nil {
+		tx.Rollback()
+		c.JSON(oerr.status, oerr.body)
+		return
+	}
 
-	var diskon *app.Diskon
-
-	var stanID uint
-	var total float64
-	var details []app.DetailTransaksi
-
-	for _, it := range p.Items {
-		var menu app.Menu
-		if err := tx.
-			Where("public_id = ?", it.MenuID).
-			First(&menu).Error; err != nil {
+	// ❌ campur stan tidak boleh di sini → pakai checkout keranjang
+	if len(orders) > 1 {
+		tx.Rollback()
+		c.JSON(http.StatusBadRequest, gin.H{
+			"error": "mixed stans not allowed",
+			"hint":  "use POST /api/siswa/cart/checkout for multi-stan orders",
+		})
+		return
+	}
+	so := orders[0]
+
+	method := app.PaymentMethod(p.PaymentMethod)
+	if oerr := placeStanOrder(tx, so, placeOrderOpts{
+		siswaID:    siswa.ID,
+		method:     method,
+		pickupSlot: p.PickupSlot,
+		now:        time.Now(),
+	}); oerr != nil {
+		tx.Rollback()
+		c.JSON(oerr.status, oerr.body)
+		return
+	}
 
+	// 🎟️ voucher (opsional): validasi + catat pemakaian di tx yang sama
+	if code := app.NormalizeVoucherCode(p.VoucherCode); code != "" {
+		if oerr := redeemOrderVoucher(tx, code, user.ID, so); oerr != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "menu not found"})
+			c.JSON(oerr.status, oerr.body)
 			return
 		}
+	}
 
-		// ❌ campur stan tidak boleh
-		if stanID == 0 {
-			stanID = menu.StanID
-			diskon = app.GetActiveDiscountByStan(stanID)
-		} else if menu.StanID != stanID {
+	trx := so.trx
+	stanID := so.stanID
+	total := so.total()
+	voucherInfo := so.voucher
+
+	// 💳 bayar pakai saldo: lock user → cek saldo → debit → ledger
+	// cash: dibayar di stan, payment_status tetap "pending"
+	var saldo interface{} = nil
+	if method == app.PaymentWallet {
+		after, err := app.DebitWallet(tx, user.ID, total, &trx.ID, "order "+trx.PublicID)
+		if err != nil {
 			tx.Rollback()
-			c.JSON(http.StatusBadRequest, gin.H{"error": "mixed stans not allowed"})
+			if errors.Is(err, app.ErrInsufficientSaldo) {
+				c.JSON(http.StatusPaymentRequired, gin.H{
+					"error": "saldo tidak cukup",
+					"saldo": app.Round2(after),
+					"total": app.Round2(total),
+				})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to debit wallet"})
 			return
 		}
+		saldo = after
+	}
 
-		// 💰 harga final (apply diskon DI SINI)
-		harga := app.Round2(menu.Harga)
-		if diskon != nil {
-			harga = app.ApplyDiscount(harga, diskon.Persentase)
+	resp := gin.H{
+		"transaksi_id":   trx.PublicID,
+		"status":         trx.Status,
+		"total":          app.Round2(total),
+		"payment_method": trx.PaymentMethod,
+		"payment_status": trx.PaymentStatus,
+		"saldo":          saldo,
+		"voucher":        voucherInfo,
+		"pickup":         app.PickupJSON(trx),
+		"short_code":     trx.ShortCode(),
+		"qr_payload":     app.OrderQRPayload(trx.PublicID, getStanPublicIDByID(stanID)),
+	}
+
+	if idemKey != "" {
+		if err := saveIdempotencyKey(tx, user.ID, idemKey, idemHash, http.StatusCreated, resp); err != nil {
+			tx.Rollback()
+			// request paralel dengan key sama sudah commit duluan
+			if prev := findIdempotencyKey(app.DB, user.ID, idemKey); prev != nil {
+				replayIdempotent(c, prev, idemHash)
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save idempotency key"})
+			return
 		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
+		return
+	}
 
-		sub := float64(it.Qty) * harga
-		total += sub
+	app.PublishOrderEvent(&trx, app.EventOrderCreated)
 
-		details = append(details, app.DetailTransaksi{
-			MenuID:    menu.ID,
-			Qty:       it.Qty,
-			HargaBeli: harga, // 🔥 harga sudah diskon
-			CreatedAt: time.Now(),
-		})
+	c.JSON(http.StatusCreated, resp)
+}
+
+// =========================
+// CANCEL ORDER (SISWA)
+// =========================
+// POST /api/siswa/orders/:id/cancel
+// hanya boleh selama status "belum dikonfirm".
+// order wallet → saldo otomatis dikembalikan.
+func SiswaCancelOrder(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
 	}
 
-	trx := app.Transaksi{
-		PublicID: uuid.NewString(),
-		StanID:   stanID,
-		SiswaID:  siswa.ID,
-		Status:   app.StatusBelumDikonfirm,
+	var p struct {
+		Reason string `json:"reason,omitempty"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&p); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
 	}
 
-	if err := tx.Create(&trx).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create transaksi"})
+	var siswa app.Siswa
+	if err := app.DB.
+		Where("user_id = ?", user.ID).
+		First(&siswa).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not siswa"})
+		return
+	}
+
+	var trx app.Transaksi
+	if err := app.DB.
+		Where("public_id = ? AND siswa_id = ?", c.Param("id"), siswa.ID).
+		First(&trx).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
 		return
 	}
 
-	for i := range details {
-		details[i].TransaksiID = trx.ID
-		if err := tx.Create(&details[i]).Error; err != nil {
+	if trx.Status != app.StatusBelumDikonfirm {
+		c.JSON(http.StatusConflict, gin.H{
+			"error":  "order can only be cancelled before it is confirmed",
+			"status": trx.Status,
+		})
+		return
+	}
+
+	tx := app.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
 			tx.Rollback()
-			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create detail"})
+		}
+	}()
+
+	refund, err := app.CancelTransaksi(
+		tx, &trx,
+		[]app.TransaksiStatus{app.StatusBelumDikonfirm},
+		app.StatusDibatalkan,
+		p.Reason,
+	)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, app.ErrStatusChanged) {
+			c.JSON(http.StatusConflict, gin.H{"error": "status already changed"})
 			return
 		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to cancel order"})
+		return
 	}
 
 	if err := tx.Commit().Error; err != nil {
@@ -180,9 +419,13 @@ func SiswaCreateOrder(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"transaksi_id": trx.PublicID,
-		"status":       trx.Status,
-		"total":        app.Round2(total),
+	app.PublishOrderEvent(&trx, app.EventOrderCancelled)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "order cancelled",
+		"transaksi_id":   trx.PublicID,
+		"status":         trx.Status,
+		"payment_status": trx.PaymentStatus,
+		"refund":         refund,
 	})
 }
@@ -0,0 +1,76 @@
+package siswa
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+func TestCreateOrderWalletDebit(t *testing.T) {
+	cases := []struct {
+		name    string
+		method  string
+		saldo   float64
+		qty     int
+		status  int
+		after   float64 // saldo setelah request
+		debits  int64
+		payment app.PaymentStatus // "" = tidak ada order
+	}{
+		{"wallet cukup", "wallet", 50000, 3, http.StatusCreated, 20000, 1, app.PaymentPaid},
+		{"wallet pas", "wallet", 30000, 3, http.StatusCreated, 0, 1, app.PaymentPaid},
+		{"wallet kurang", "wallet", 20000, 3, http.StatusPaymentRequired, 20000, 0, ""},
+		{"tunai tidak memotong saldo", "cash", 5000, 3, http.StatusCreated, 5000, 0, app.PaymentPending},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			db := apptest.OpenDB(t)
+			user, _ := apptest.SeedSiswa(t, db, tc.saldo)
+			stan := apptest.SeedStan(t, db, "Kantin Wallet")
+			menu := apptest.SeedMenu(t, db, stan.ID, "Nasi Goreng", 10000)
+
+			w := callHandler(t, SiswaCreateOrder, user, http.MethodPost, CreateOrderPayload{
+				Items:         []OrderItemPayload{{MenuID: menu.PublicID, Qty: tc.qty}},
+				PaymentMethod: tc.method,
+			})
+			if w.Code != tc.status {
+				t.Fatalf("status = %d, want %d (body %s)", w.Code, tc.status, w.Body.String())
+			}
+
+			var u app.User
+			db.First(&u, user.ID)
+			if u.Saldo != tc.after {
+				t.Errorf("saldo = %v, want %v", u.Saldo, tc.after)
+			}
+			if ledger, _ := app.LedgerSaldo(db, user.ID); ledger != u.Saldo {
+				t.Errorf("ledger %v != saldo %v", ledger, u.Saldo)
+			}
+
+			var debits []app.WalletTransaction
+			db.Where("type = ?", app.WalletDebit).Find(&debits)
+			if int64(len(debits)) != tc.debits {
+				t.Fatalf("debit rows = %d, want %d", len(debits), tc.debits)
+			}
+
+			var trxs []app.Transaksi
+			db.Find(&trxs)
+			if tc.payment == "" {
+				if len(trxs) != 0 {
+					t.Errorf("transaksi = %d, want 0 (rollback)", len(trxs))
+				}
+				return
+			}
+			if len(trxs) != 1 || trxs[0].PaymentStatus != tc.payment {
+				t.Fatalf("transaksi = %+v, want 1 with payment %s", trxs, tc.payment)
+			}
+			if tc.debits > 0 {
+				d := debits[0]
+				if d.TransaksiID == nil || *d.TransaksiID != trxs[0].ID || d.Amount != -30000 {
+					t.Errorf("debit = %+v, want transaksi %d amount -30000", d, trxs[0].ID)
+				}
+			}
+		})
+	}
+}
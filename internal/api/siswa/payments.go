@@ -4,13 +4,10 @@ import (
 	// "log"
 	"net/http"
 	// "strings"
-	"time"
 
 	"github.com/gin-gonic/gin"
-	"gorm.io/gorm"
 	// "gorm.io/gorm/clause"
 
-	"github.com/google/uuid"
 	"github.com/samudsamudra/UKK_kantin/internal/app"
 )
 
@@ -24,6 +21,10 @@ type CreateOrderPayload struct {
 	PaymentMethod string             `json:"payment_method" binding:"required,oneof=wallet cash"`
 	// optional: client can send idempotency key header instead
 	IdempotencyKey *string `json:"idempotency_key,omitempty"`
+	// optional: kode promo (voucher)
+	VoucherCode string `json:"voucher_code,omitempty"`
+	// optional: pre-order, ambil di slot istirahat hari ini (slot_id)
+	PickupSlot string `json:"pickup_slot,omitempty"`
 }
 
 // GET /api/siswa/wallet - get current user's saldo
@@ -41,8 +42,115 @@ func SiswaGetWallet(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"saldo": u.Saldo})
 }
 
-// POST /api/siswa/topup - admin/operator topup (for quick testing or manual topup)
+// GET /api/siswa/wallet/transactions?page=&limit=
+// riwayat ledger saldo milik siswa (terbaru dulu)
+func SiswaWalletTransactions(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	pg := app.ParsePagination(c)
+	q := app.DB.Model(&app.WalletTransaction{}).Where("user_id = ?", user.ID)
+
+	var total int64
+	if err := q.Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count wallet transactions"})
+		return
+	}
+
+	var rows []app.WalletTransaction
+	if err := q.
+		Order("created_at DESC, id DESC").
+		Offset(pg.Offset()).
+		Limit(pg.Limit).
+		Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch wallet transactions"})
+		return
+	}
+
+	// map transaksi_id internal → public_id (1 query)
+	trxIDs := make([]uint, 0, len(rows))
+	for _, w := range rows {
+		if w.TransaksiID != nil {
+			trxIDs = append(trxIDs, *w.TransaksiID)
+		}
+	}
+	trxPub := map[uint]string{}
+	if len(trxIDs) > 0 {
+		var trxs []app.Transaksi
+		app.DB.Select("id", "public_id").Where("id IN ?", trxIDs).Find(&trxs)
+		for _, t := range trxs {
+			trxPub[t.ID] = t.PublicID
+		}
+	}
+
+	// checkout keranjang: 1 debit untuk beberapa order
+	coIDs := []uint{}
+	for _, w := range rows {
+		if w.CheckoutID != nil {
+			coIDs = append(coIDs, *w.CheckoutID)
+		}
+	}
+	coPub := map[uint]string{}
+	if len(coIDs) > 0 {
+		var cos []app.Checkout
+		app.DB.Select("id", "public_id").Where("id IN ?", coIDs).Find(&cos)
+		for _, co := range cos {
+			coPub[co.ID] = co.PublicID
+		}
+	}
+
+	out := make([]gin.H, 0, len(rows))
+	for _, w := range rows {
+		var trxID interface{} = nil
+		if w.TransaksiID != nil {
+			trxID = trxPub[*w.TransaksiID]
+		}
+		var coID interface{} = nil
+		if w.CheckoutID != nil {
+			coID = coPub[*w.CheckoutID]
+		}
+
+		out = append(out, gin.H{
+			"wallet_tx_id":     w.PublicID,
+			"type":             w.Type,
+			"amount":           app.Round2(w.Amount),
+			"saldo_after":      app.Round2(w.SaldoAfter),
+			"note":             w.Note,
+			"transaksi_id":     trxID,
+			"checkout_id":      coID,
+			"created_at":       w.CreatedAt,
+			"created_at_human": app.FormatTimeWithClock(w.CreatedAt),
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"saldo":        app.Round2(user.Saldo),
+		"transactions": out,
+		"pagination":   pg.Meta(total),
+	})
+}
+
+// POST /api/admin/wallet/topup
+// 🔒 super_admin | kasir
+// top-up saldo siswa, operator tercatat di ledger
 func SiswaTopupByAdmin(c *gin.Context) {
+	// defense-in-depth
+	roleAny, ok := c.Get("role")
+	role, _ := roleAny.(string)
+	if !ok || (role != string(app.RoleSuperAdmin) && role != string(app.RoleKasir)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "super admin or kasir only"})
+		return
+	}
+
+	operator, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
 	var payload struct {
 		UserPublicID string  `json:"user_public_id" binding:"required"`
 		Amount       float64 `json:"amount" binding:"required,gt=0"`
@@ -58,6 +166,10 @@ func SiswaTopupByAdmin(c *gin.Context) {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return
 	}
+	if user.Role != app.RoleSiswa {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "topup only allowed for siswa"})
+		return
+	}
 
 	tx := app.DB.Begin()
 	defer func() {
@@ -66,33 +178,26 @@ func SiswaTopupByAdmin(c *gin.Context) {
 		}
 	}()
 
-	if err := tx.Model(&app.User{}).Where("id = ?", user.ID).UpdateColumn("saldo", gorm.Expr("saldo + ?", payload.Amount)).Error; err != nil {
+	saldo, err := app.CreditWallet(tx, user.ID, payload.Amount, &operator.ID, payload.Note)
+	if err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to topup"})
 		return
 	}
 
-	wtx := app.WalletTransaction{
-		PublicID:  uuid.NewString(),
-		UserID:    user.ID,
-		Amount:    payload.Amount,
-		Type:      "topup",
-		Note:      payload.Note,
-		CreatedAt: time.Now(),
-	}
-	if err := tx.Create(&wtx).Error; err != nil {
-		tx.Rollback()
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to record wallet tx"})
-		return
-	}
-
 	if err := tx.Commit().Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "commit failed"})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "topup successful"})
+	c.JSON(http.StatusOK, gin.H{
+		"message":        "topup successful",
+		"user_public_id": user.PublicID,
+		"amount":         app.Round2(payload.Amount),
+		"saldo":          saldo,
+		"operator":       operator.Email,
+	})
 }
 
 // robust getUserFromContext: accepts either *app.User, numeric user id (uint/float64) or public_id string.
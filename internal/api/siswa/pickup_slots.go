@@ -0,0 +1,88 @@
+package siswa
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// PICKUP SLOT (SISWA)
+// =========================
+//
+
+// GET /api/siswa/pickup-slots
+// Slot aktif untuk hari ini + batas waktu order.
+// optional: ?stan_id=<stan_public_id> (tambah sisa kapasitas di stan tsb)
+func SiswaListPickupSlots(c *gin.Context) {
+	var stan *app.Stan
+	if pub := c.Query("stan_id"); pub != "" {
+		var s app.Stan
+		if err := app.DB.Where("public_id = ?", pub).First(&s).Error; err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "stan not found"})
+			return
+		}
+		stan = &s
+	}
+
+	var slots []app.PickupSlot
+	if err := app.DB.
+		Where("is_active = ?", true).
+		Order("jam_mulai ASC, nama ASC").
+		Find(&slots).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch pickup slots"})
+		return
+	}
+
+	now := time.Now()
+	var used map[uint]int64
+	if stan != nil {
+		u, err := app.PickupSlotUsage(app.DB, stan.ID, now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to count pickup slots"})
+			return
+		}
+		used = u
+	}
+
+	out := make([]gin.H, 0, len(slots))
+	for _, s := range slots {
+		cutoff := s.CutoffOn(now)
+		item := gin.H{
+			"slot_id":           s.PublicID,
+			"nama":              s.Nama,
+			"jam_mulai":         s.JamMulai,
+			"jam_selesai":       s.JamSelesai,
+			"pickup_at":         s.StartOn(now),
+			"order_until":       cutoff,
+			"order_until_human": app.FormatTimeWithClock(cutoff),
+			"open":              now.Before(cutoff),
+		}
+
+		if stan != nil {
+			var sisa interface{} = nil // null = tanpa batas
+			full := false
+			if s.Kapasitas > 0 {
+				left := int64(s.Kapasitas) - used[s.ID]
+				if left < 0 {
+					left = 0
+				}
+				sisa = left
+				full = left == 0
+			}
+			item["kapasitas_sisa"] = sisa
+			item["full"] = full
+		}
+
+		out = append(out, item)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tanggal":      app.FormatDateID(&now, false),
+		"pickup_slots": out,
+	})
+}
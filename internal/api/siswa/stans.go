@@ -0,0 +1,116 @@
+package siswa
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+//
+// =========================
+// DIREKTORI STAN (SISWA)
+// =========================
+//
+
+// menuCountsByStan jumlah menu yang bisa dipesan per stan (1 query)
+func menuCountsByStan(ids []uint) map[uint]int64 {
+	out := map[uint]int64{}
+	if len(ids) == 0 {
+		return out
+	}
+	var rows []struct {
+		StanID uint
+		Total  int64
+	}
+	app.DB.Model(&app.Menu{}).
+		Select("stan_id, COUNT(*) AS total").
+		Where("stan_id IN ? AND is_available = ? AND (stok IS NULL OR stok > 0)", ids, true).
+		Group("stan_id").
+		Scan(&rows)
+	for _, r := range rows {
+		out[r.StanID] = r.Total
+	}
+	return out
+}
+
+func stanDirectoryJSON(s app.Stan, menuCount int64, sched app.StanSchedule, now time.Time) gin.H {
+	out := sched.ScheduleJSON(now)
+	out["id"] = s.PublicID
+	out["nama_stan"] = s.NamaStan
+	out["menu_count"] = menuCount
+	return out
+}
+
+// GET /api/siswa/stans
+// optional: ?open_now=true (hanya stan yang sedang buka)
+func SiswaListStans(c *gin.Context) {
+	var stans []app.Stan
+	if err := app.DB.Order("nama_stan ASC, id ASC").Find(&stans).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stans"})
+		return
+	}
+
+	ids := make([]uint, 0, len(stans))
+	for _, s := range stans {
+		ids = append(ids, s.ID)
+	}
+
+	now := time.Now()
+	schedules, err := app.LoadStanSchedules(app.DB, ids, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load jam buka"})
+		return
+	}
+	counts := menuCountsByStan(ids)
+
+	openOnly := c.Query("open_now") == "true"
+
+	out := make([]gin.H, 0, len(stans))
+	for _, s := range stans {
+		sched := schedules[s.ID]
+		if openOnly && !sched.StatusAt(now).Open {
+			continue
+		}
+		out = append(out, stanDirectoryJSON(s, counts[s.ID], sched, now))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"stans": out,
+		"total": len(out),
+	})
+}
+
+// GET /api/siswa/stans/:id (detail + kategori menu)
+func SiswaGetStan(c *gin.Context) {
+	var s app.Stan
+	if err := app.DB.Where("public_id = ?", c.Param("id")).First(&s).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "stan not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to fetch stan"})
+		return
+	}
+
+	now := time.Now()
+	schedules, err := app.LoadStanSchedules(app.DB, []uint{s.ID}, now)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load jam buka"})
+		return
+	}
+
+	var kategori []app.MenuKategori
+	app.DB.Where("stan_id = ?", s.ID).Order("urutan ASC, nama ASC").Find(&kategori)
+	kats := make([]interface{}, 0, len(kategori))
+	for i := range kategori {
+		kats = append(kats, kategoriJSON(&kategori[i]))
+	}
+
+	out := stanDirectoryJSON(s, menuCountsByStan([]uint{s.ID})[s.ID], schedules[s.ID], now)
+	out["kategori"] = kats
+	c.JSON(http.StatusOK, out)
+}
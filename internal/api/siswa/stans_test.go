@@ -0,0 +1,33 @@
+package siswa
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// direktori stan publik (tanpa login) tidak boleh membocorkan data pemilik
+func TestStanDirectoryHidesOwnerContact(t *testing.T) {
+	db := apptest.OpenDB(t)
+	stan := apptest.SeedStan(t, db, "Kantin Publik")
+	db.Model(stan).Updates(map[string]interface{}{"nama_pemilik": "Bu Siti", "telp": "081234567890"})
+
+	w := callHandler(t, SiswaListStans, nil, http.MethodGet, nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, body %s", w.Code, w.Body.String())
+	}
+	stans, _ := decodeBody(t, w)["stans"].([]interface{})
+	if len(stans) != 1 {
+		t.Fatalf("stans = %v", decodeBody(t, w))
+	}
+	got := stans[0].(map[string]interface{})
+	if got["nama_stan"] != "Kantin Publik" {
+		t.Errorf("nama_stan = %v", got["nama_stan"])
+	}
+	for _, field := range []string{"telp", "nama_pemilik"} {
+		if _, ok := got[field]; ok {
+			t.Errorf("field %q ikut terkirim di direktori publik", field)
+		}
+	}
+}
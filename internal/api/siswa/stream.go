@@ -0,0 +1,30 @@
+package siswa
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// GET /api/siswa/orders/stream
+// Server-Sent Events: perubahan status order milik siswa ini.
+// Reconnect: kirim header Last-Event-ID (otomatis oleh EventSource).
+func SiswaOrdersStream(c *gin.Context) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return
+	}
+
+	var siswa app.Siswa
+	if err := app.DB.
+		Where("user_id = ?", user.ID).
+		First(&siswa).Error; err != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not siswa"})
+		return
+	}
+
+	app.ServeSSE(c, app.TopicSiswa(siswa.ID))
+}
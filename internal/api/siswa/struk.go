@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -15,44 +16,36 @@ import (
 
 // GET /api/siswa/orders/:id/receipt/pdf
 // Generate struk / nota dalam bentuk PDF
+// optional: ?paper=58|80 (struk thermal, default A4)
 func SiswaGetOrderReceiptPDF(c *gin.Context) {
-	user, ok := getUserFromContext(c)
+	trx, stan, siswa, ok := loadOwnReceipt(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
-		return
-	}
-
-	transaksiID := c.Param("id")
-	if transaksiID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "transaksi id required"})
-		return
-	}
-
-	// 🔑 Ambil siswa dari user_id
-	var siswa app.Siswa
-	if err := app.DB.
-		Where("user_id = ?", user.ID).
-		First(&siswa).Error; err != nil {
-
-		c.JSON(http.StatusForbidden, gin.H{"error": "user is not siswa"})
 		return
 	}
 
-	// Ambil transaksi + detail + menu (pastikan milik siswa ini)
-	var trx app.Transaksi
-	if err := app.DB.
-		Preload("Details.Menu").
-		Where("public_id = ? AND siswa_id = ?", transaksiID, siswa.ID).
-		First(&trx).Error; err != nil {
-
-		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+	// =========================
+	// THERMAL (58 / 80 mm)
+	// =========================
+	if c.Query("paper") != "" {
+		paper, err := app.ParsePaperWidth(c.Query("paper"), app.Paper80)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		out, err := app.NewReceipt(*trx, stan, siswa.Nama).ThermalPDF(paper)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate pdf"})
+			return
+		}
+		c.Header(
+			"Content-Disposition",
+			fmt.Sprintf("inline; filename=struk-%s-%dmm.pdf", trx.PublicID, paper),
+		)
+		c.Data(http.StatusOK, "application/pdf", out)
 		return
 	}
 
-	// Ambil stan
-	var stan app.Stan
-	_ = app.DB.Where("id = ?", trx.StanID).First(&stan)
-
 	// =========================
 	// PDF SETUP
 	// =========================
@@ -75,33 +68,87 @@ func SiswaGetOrderReceiptPDF(c *gin.Context) {
 	pdf.Cell(0, 7, "Tanggal    : "+formatTanggalStruk(trx.CreatedAt))
 	pdf.Ln(6)
 	pdf.Cell(0, 7, "Status     : "+string(trx.Status))
+	if trx.PickupAt != nil {
+		pdf.Ln(6)
+		pdf.Cell(0, 7, "Ambil      : "+trx.PickupSlotNama+", "+formatTanggalStruk(*trx.PickupAt))
+	}
 	pdf.Ln(10)
 
 	// Header tabel
-	pdf.SetFont("Arial", "B", 11)
-	pdf.CellFormat(80, 8, "Menu", "1", 0, "", false, 0, "")
-	pdf.CellFormat(20, 8, "Qty", "1", 0, "C", false, 0, "")
-	pdf.CellFormat(40, 8, "Harga", "1", 0, "R", false, 0, "")
-	pdf.CellFormat(40, 8, "Subtotal", "1", 1, "R", false, 0, "")
+	pdf.SetFont("Arial", "B", 10)
+	pdf.CellFormat(50, 8, "Menu", "1", 0, "", false, 0, "")
+	pdf.CellFormat(12, 8, "Qty", "1", 0, "C", false, 0, "")
+	pdf.CellFormat(30, 8, "Harga Normal", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, "Diskon", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(28, 8, "Harga Akhir", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, "Subtotal", "1", 1, "R", false, 0, "")
 
-	pdf.SetFont("Arial", "", 11)
+	pdf.SetFont("Arial", "", 10)
 
-	var total float64
+	var total, normal float64
+	diskonNames := []string{}
+	seen := map[string]bool{}
 
 	for _, d := range trx.Details {
-		sub := float64(d.Qty) * d.HargaBeli
+		sub := d.Subtotal()
 		total += sub
-
-		pdf.CellFormat(80, 8, d.Menu.NamaMakanan, "1", 0, "", false, 0, "")
-		pdf.CellFormat(20, 8, strconv.Itoa(d.Qty), "1", 0, "C", false, 0, "")
-		pdf.CellFormat(40, 8, formatRupiah(d.HargaBeli), "1", 0, "R", false, 0, "")
-		pdf.CellFormat(40, 8, formatRupiah(sub), "1", 1, "R", false, 0, "")
+		normal += d.GrossSubtotal()
+
+		potongan := "-"
+		if d.DiskonAmount() > 0 {
+			potongan = "-" + formatRupiah(d.OriginalPrice()-d.HargaBeli)
+			if d.DiskonNama != "" && !seen[d.DiskonNama] {
+				seen[d.DiskonNama] = true
+				diskonNames = append(diskonNames, fmt.Sprintf("%s (%.0f%%)", d.DiskonNama, d.DiskonPersen))
+			}
+		}
+
+		pdf.CellFormat(50, 8, d.MenuName(), "1", 0, "", false, 0, "")
+		pdf.CellFormat(12, 8, strconv.Itoa(d.Qty), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(30, 8, formatRupiah(d.OriginalPrice()), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, potongan, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(28, 8, formatRupiah(d.HargaBeli), "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, formatRupiah(sub), "1", 1, "R", false, 0, "")
 	}
 
 	// Total
+	if normal > total {
+		pdf.CellFormat(150, 8, "Harga Normal", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, formatRupiah(normal), "1", 1, "R", false, 0, "")
+		pdf.CellFormat(150, 8, "Total Diskon", "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, "-"+formatRupiah(normal-total), "1", 1, "R", false, 0, "")
+	}
+	if trx.VoucherPotongan > 0 {
+		pdf.CellFormat(150, 8, "Voucher "+trx.VoucherKode, "1", 0, "R", false, 0, "")
+		pdf.CellFormat(30, 8, "-"+formatRupiah(trx.VoucherPotongan), "1", 1, "R", false, 0, "")
+	}
 	pdf.SetFont("Arial", "B", 11)
-	pdf.CellFormat(140, 8, "TOTAL", "1", 0, "R", false, 0, "")
-	pdf.CellFormat(40, 8, formatRupiah(total), "1", 1, "R", false, 0, "")
+	pdf.CellFormat(150, 8, "TOTAL", "1", 0, "R", false, 0, "")
+	pdf.CellFormat(30, 8, formatRupiah(trx.Total()), "1", 1, "R", false, 0, "")
+
+	if len(diskonNames) > 0 {
+		pdf.Ln(4)
+		pdf.SetFont("Arial", "I", 10)
+		pdf.Cell(0, 7, "Diskon: "+strings.Join(diskonNames, ", "))
+		pdf.Ln(6)
+	}
+
+	// QR verifikasi pengambilan (discan staf stan)
+	if stan.PublicID != "" {
+		png, err := app.OrderQRPNG(app.OrderQRPayload(trx.PublicID, stan.PublicID), 256)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to generate qr"})
+			return
+		}
+
+		pdf.Ln(6)
+		opt := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("qr", opt, bytes.NewReader(png))
+		pdf.ImageOptions("qr", 15, pdf.GetY(), 40, 40, false, opt, 0, "")
+		pdf.SetXY(60, pdf.GetY()+15)
+		pdf.SetFont("Arial", "", 10)
+		pdf.MultiCell(0, 5, "Tunjukkan QR ini ke stan saat mengambil pesanan.\nKode order: "+trx.ShortCode(), "", "", false)
+	}
 
 	// Output PDF ke buffer
 	var buf bytes.Buffer
@@ -118,6 +165,27 @@ func SiswaGetOrderReceiptPDF(c *gin.Context) {
 	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
 }
 
+// GET /api/siswa/orders/:id/receipt/escpos?paper=58|80
+// Struk mentah ESC/POS (default 80mm) untuk printer thermal
+func SiswaGetOrderReceiptESCPOS(c *gin.Context) {
+	trx, stan, siswa, ok := loadOwnReceipt(c)
+	if !ok {
+		return
+	}
+
+	paper, err := app.ParsePaperWidth(c.Query("paper"), app.Paper80)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header(
+		"Content-Disposition",
+		fmt.Sprintf("attachment; filename=struk-%s-%dmm.bin", trx.PublicID, paper),
+	)
+	c.Data(http.StatusOK, "application/octet-stream", app.NewReceipt(*trx, stan, siswa.Nama).ESCPOS(paper))
+}
+
 // =========================
 // HELPER (KHUSUS STRUK)
 // =========================
@@ -136,3 +204,46 @@ func formatTanggalStruk(t time.Time) string {
 func formatRupiah(v float64) string {
 	return fmt.Sprintf("Rp %.2f", app.Round2(v))
 }
+
+// loadOwnReceipt mengambil transaksi milik siswa yang login (+ detail & stan).
+// Response error sudah dikirim jika ok = false.
+func loadOwnReceipt(c *gin.Context) (*app.Transaksi, app.Stan, app.Siswa, bool) {
+	user, ok := getUserFromContext(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		return nil, app.Stan{}, app.Siswa{}, false
+	}
+
+	transaksiID := c.Param("id")
+	if transaksiID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "transaksi id required"})
+		return nil, app.Stan{}, app.Siswa{}, false
+	}
+
+	// 🔑 Ambil siswa dari user_id
+	var siswa app.Siswa
+	if err := app.DB.
+		Where("user_id = ?", user.ID).
+		First(&siswa).Error; err != nil {
+
+		c.JSON(http.StatusForbidden, gin.H{"error": "user is not siswa"})
+		return nil, app.Stan{}, app.Siswa{}, false
+	}
+
+	// Ambil transaksi + detail + menu (pastikan milik siswa ini)
+	var trx app.Transaksi
+	if err := app.DB.
+		Preload("Details.Menu").
+		Where("public_id = ? AND siswa_id = ?", transaksiID, siswa.ID).
+		First(&trx).Error; err != nil {
+
+		c.JSON(http.StatusNotFound, gin.H{"error": "transaction not found"})
+		return nil, app.Stan{}, app.Siswa{}, false
+	}
+
+	// Ambil stan
+	var stan app.Stan
+	_ = app.DB.Where("id = ?", trx.StanID).First(&stan)
+
+	return &trx, stan, siswa, true
+}
@@ -10,17 +10,35 @@ import (
 )
 
 // --- auth / user ---
-func RegisterUser(c *gin.Context) { userpkg.RegisterUser(c) }
-func Login(c *gin.Context)        { authpkg.Login(c) }
+func RegisterUser(c *gin.Context)   { userpkg.RegisterUser(c) }
+func Login(c *gin.Context)          { authpkg.Login(c) }
+func ChangePassword(c *gin.Context) { authpkg.ChangePassword(c) }
+func Refresh(c *gin.Context)        { authpkg.Refresh(c) }
+func Logout(c *gin.Context)         { authpkg.Logout(c) }
+func LogoutAll(c *gin.Context)      { authpkg.LogoutAll(c) }
 
 // --- siswa ---
-func SiswaListMenus(c *gin.Context)   { siswapkg.SiswaListMenus(c) }
-func SiswaCreateOrder(c *gin.Context) { siswapkg.SiswaCreateOrder(c) }
+func SiswaListMenus(c *gin.Context)    { siswapkg.SiswaListMenus(c) }
+func SiswaCreateOrder(c *gin.Context)  { siswapkg.SiswaCreateOrder(c) }
+func SiswaCancelOrder(c *gin.Context)  { siswapkg.SiswaCancelOrder(c) }
+func SiswaOrdersStream(c *gin.Context) { siswapkg.SiswaOrdersStream(c) }
 
 // func SiswaOrdersByMonth(c *gin.Context) { siswapkg.SiswaOrdersByMonth(c) }
 // func SiswaGetReceiptPDF(c *gin.Context) { siswapkg.SiswaGetReceiptPDF(c) }
-func SiswaGetMenu(c *gin.Context)            { siswapkg.SiswaGetMenu(c) }
-func SiswaGetOrderReceiptPDF(c *gin.Context) { siswapkg.SiswaGetOrderReceiptPDF(c) }
+func SiswaGetMenu(c *gin.Context)               { siswapkg.SiswaGetMenu(c) }
+func SiswaGetOrderReceiptPDF(c *gin.Context)    { siswapkg.SiswaGetOrderReceiptPDF(c) }
+func SiswaGetOrderReceiptESCPOS(c *gin.Context) { siswapkg.SiswaGetOrderReceiptESCPOS(c) }
+func SiswaListStans(c *gin.Context)             { siswapkg.SiswaListStans(c) }
+func SiswaGetStan(c *gin.Context)               { siswapkg.SiswaGetStan(c) }
+func SiswaListPickupSlots(c *gin.Context)       { siswapkg.SiswaListPickupSlots(c) }
+
+// --- siswa (keranjang) ---
+func SiswaGetCart(c *gin.Context)        { siswapkg.SiswaGetCart(c) }
+func SiswaAddCartItem(c *gin.Context)    { siswapkg.SiswaAddCartItem(c) }
+func SiswaUpdateCartItem(c *gin.Context) { siswapkg.SiswaUpdateCartItem(c) }
+func SiswaRemoveCartItem(c *gin.Context) { siswapkg.SiswaRemoveCartItem(c) }
+func SiswaClearCart(c *gin.Context)      { siswapkg.SiswaClearCart(c) }
+func SiswaCheckoutCart(c *gin.Context)   { siswapkg.SiswaCheckoutCart(c) }
 
 // --- admin / stan (menus) ---
 func AdminCreateMenu(c *gin.Context) { adminpkg.AdminCreateMenu(c) }
@@ -29,6 +47,22 @@ func AdminDeleteMenu(c *gin.Context) { adminpkg.AdminDeleteMenu(c) }
 func AdminListMenus(c *gin.Context)  { adminpkg.AdminListMenus(c) }
 func AdminGetMenu(c *gin.Context)    { adminpkg.AdminGetMenu(c) }
 
+// --- admin / stan (kategori menu) ---
+func AdminCreateKategori(c *gin.Context) { adminpkg.AdminCreateKategori(c) }
+func AdminListKategori(c *gin.Context)   { adminpkg.AdminListKategori(c) }
+func AdminUpdateKategori(c *gin.Context) { adminpkg.AdminUpdateKategori(c) }
+func AdminDeleteKategori(c *gin.Context) { adminpkg.AdminDeleteKategori(c) }
+
+// --- admin / stan (jam buka & tutup sementara) ---
+func AdminGetStanHours(c *gin.Context)      { adminpkg.AdminGetStanHours(c) }
+func AdminSetStanHours(c *gin.Context)      { adminpkg.AdminSetStanHours(c) }
+func AdminCreateStanClosure(c *gin.Context) { adminpkg.AdminCreateStanClosure(c) }
+func AdminDeleteStanClosure(c *gin.Context) { adminpkg.AdminDeleteStanClosure(c) }
+
+// --- admin / stan (menu image) ---
+func AdminUploadMenuImage(c *gin.Context) { adminpkg.AdminUploadMenuImage(c) }
+func AdminDeleteMenuImage(c *gin.Context) { adminpkg.AdminDeleteMenuImage(c) }
+
 // --- admin / stan (discounts) ---
 func AdminCreateDiscount(c *gin.Context) { adminpkg.AdminCreateDiscount(c) }
 func AdminListDiscounts(c *gin.Context)  { adminpkg.AdminListDiscounts(c) }
@@ -36,19 +70,48 @@ func AdminGetDiscount(c *gin.Context)    { adminpkg.AdminGetDiscount(c) }
 func AdminUpdateDiscount(c *gin.Context) { adminpkg.AdminUpdateDiscount(c) }
 func AdminDeleteDiscount(c *gin.Context) { adminpkg.AdminDeleteDiscount(c) }
 
+// --- system (super admin): diskon lintas stan ---
+func AdminListAllDiscounts(c *gin.Context) { adminpkg.AdminListAllDiscounts(c) }
+
+// --- admin / vouchers (super admin) ---
+func AdminCreateVoucher(c *gin.Context) { adminpkg.AdminCreateVoucher(c) }
+func AdminListVouchers(c *gin.Context)  { adminpkg.AdminListVouchers(c) }
+func AdminGetVoucher(c *gin.Context)    { adminpkg.AdminGetVoucher(c) }
+func AdminUpdateVoucher(c *gin.Context) { adminpkg.AdminUpdateVoucher(c) }
+func AdminDeleteVoucher(c *gin.Context) { adminpkg.AdminDeleteVoucher(c) }
+
+// --- admin / pickup slot pre-order (super admin) ---
+func AdminCreatePickupSlot(c *gin.Context) { adminpkg.AdminCreatePickupSlot(c) }
+func AdminListPickupSlots(c *gin.Context)  { adminpkg.AdminListPickupSlots(c) }
+func AdminUpdatePickupSlot(c *gin.Context) { adminpkg.AdminUpdatePickupSlot(c) }
+func AdminDeletePickupSlot(c *gin.Context) { adminpkg.AdminDeletePickupSlot(c) }
+
 // --- admin / stan (orders & reports) ---
-func AdminUpdateOrderStatus(c *gin.Context) { adminpkg.AdminUpdateOrderStatus(c) }
-func AdminMonthlyReport(c *gin.Context)     { adminpkg.AdminMonthlyReport(c) }
-func AdminRekapTransaksi(c *gin.Context)    { adminpkg.AdminRekapTransaksi(c) }
+func AdminUpdateOrderStatus(c *gin.Context)  { adminpkg.AdminUpdateOrderStatus(c) }
+func AdminOrderReceiptPDF(c *gin.Context)    { adminpkg.AdminOrderReceiptPDF(c) }
+func AdminOrderReceiptESCPOS(c *gin.Context) { adminpkg.AdminOrderReceiptESCPOS(c) }
+func AdminVerifyOrderQR(c *gin.Context)      { adminpkg.AdminVerifyOrderQR(c) }
+func AdminMonthlyReport(c *gin.Context)      { adminpkg.AdminMonthlyReport(c) }
+func AdminRekapTransaksi(c *gin.Context)     { adminpkg.AdminRekapTransaksi(c) }
 
 // --- admin / stan (stan management) ---
 func RegisterStan(c *gin.Context) { adminpkg.RegisterStan(c) }
 
-func SiswaGetWallet(c *gin.Context)    { siswapkg.SiswaGetWallet(c) }
-func SiswaTopupByAdmin(c *gin.Context) { siswapkg.SiswaTopupByAdmin(c) }
+func SiswaGetWallet(c *gin.Context)          { siswapkg.SiswaGetWallet(c) }
+func SiswaWalletTransactions(c *gin.Context) { siswapkg.SiswaWalletTransactions(c) }
+func SiswaTopupByAdmin(c *gin.Context)       { siswapkg.SiswaTopupByAdmin(c) }
+
+// --- wallet (super admin / kasir) ---
+func AdminWalletAdjust(c *gin.Context) { adminpkg.AdminWalletAdjust(c) }
+func AdminWalletLedger(c *gin.Context) { adminpkg.AdminWalletLedger(c) }
+func RegisterKasir(c *gin.Context)     { adminpkg.RegisterKasir(c) }
+
+// --- system (super admin) ---
+func AdminResetPassword(c *gin.Context) { adminpkg.AdminResetPassword(c) }
 
 func SiswaOrdersByMonth(c *gin.Context) { siswapkg.SiswaOrdersByMonth(c) }
 func AdminOrders(c *gin.Context)        { adminpkg.AdminOrders(c) }
+func AdminOrdersStream(c *gin.Context)  { adminpkg.AdminOrdersStream(c) }
 
 func AdminClearDatabase(c *gin.Context) {
 	adminpkg.AdminClearDatabase(c)
@@ -6,3 +6,15 @@ func LoginErrorResponse() map[string]string {
 		"error": "email atau password salah",
 	}
 }
+
+// CodePasswordChangeRequired kode error jika user wajib ganti password dulu
+const CodePasswordChangeRequired = "PASSWORD_CHANGE_REQUIRED"
+
+// PasswordChangeRequiredResponse error untuk akun must_change_password
+func PasswordChangeRequiredResponse() map[string]string {
+	return map[string]string{
+		"error":   "password wajib diganti",
+		"code":    CodePasswordChangeRequired,
+		"message": "ganti password lewat POST /api/auth/change-password",
+	}
+}
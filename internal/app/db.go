@@ -3,6 +3,7 @@ package app
 import (
 	"log"
 	"os"
+	"time"
 
 	gormMySQL "gorm.io/driver/mysql"
 	"gorm.io/gorm"
@@ -37,15 +38,102 @@ func RunMigrations() {
 		&User{},
 		&Siswa{},
 		&Stan{},
+		&StanJamBuka{},
+		&StanTutup{},
+		&MenuKategori{},
+		&Tag{},
 		&Menu{},
 		&Diskon{},
 		&Transaksi{},
 		&DetailTransaksi{},
 		&WalletTransaction{},
+		&IdempotencyKey{},
+		&RefreshToken{},
+		&Voucher{},
+		&VoucherRedemption{},
+		&PickupSlot{},
+		&CartItem{},
+		&Checkout{},
 	)
 	if err != nil {
 		log.Fatalf("migration failed: %v", err)
 	}
 
+	if err := backfillDetailSnapshots(DB); err != nil {
+		log.Fatalf("backfill detail snapshot failed: %v", err)
+	}
+	if err := backfillWalletLedger(DB); err != nil {
+		log.Fatalf("backfill wallet ledger failed: %v", err)
+	}
+	if err := backfillCashPaid(DB); err != nil {
+		log.Fatalf("backfill cash payment status failed: %v", err)
+	}
+
 	log.Println("migrations completed")
 }
+
+// backfillDetailSnapshots mengisi snapshot nama & harga normal untuk detail
+// transaksi lama (sebelum kolom snapshot ada). Harga normal diambil dari harga
+// menu saat ini (informasi terbaik yang tersisa), minimal sama dengan harga beli.
+// Hanya menyentuh baris dengan harga_normal = 0, jadi aman dijalankan berulang.
+func backfillDetailSnapshots(db *gorm.DB) error {
+	return db.Exec(`
+		UPDATE detail_transaksis d
+		LEFT JOIN menus m ON m.id = d.menu_id
+		SET
+			d.nama_menu    = COALESCE(NULLIF(d.nama_menu, ''), m.nama_makanan, ''),
+			d.harga_normal = GREATEST(COALESCE(m.harga, 0), d.harga_beli)
+		WHERE d.harga_normal = 0
+	`).Error
+}
+
+// backfillWalletLedger mencatat "saldo awal" untuk akun yang saldonya sudah ada
+// sebelum ledger dipakai (saldo lama ditulis langsung ke users.saldo). Selisih
+// saldo - SUM(ledger) dicatat sebagai 1 adjustment di awal riwayat user, jadi
+// pengecekan konsistensi ledger tetap benar. Setelah jalan selisihnya 0 → aman
+// dijalankan berulang.
+func backfillWalletLedger(db *gorm.DB) error {
+	var rows []struct {
+		ID        uint
+		Saldo     float64
+		Ledger    float64
+		CreatedAt time.Time
+	}
+	if err := db.Table("users").
+		Select("users.id, users.saldo, users.created_at, COALESCE(SUM(w.amount), 0) AS ledger").
+		Joins("LEFT JOIN wallet_transactions w ON w.user_id = users.id").
+		Group("users.id, users.saldo, users.created_at").
+		Scan(&rows).Error; err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, r := range rows {
+			diff := Round2(r.Saldo - r.Ledger)
+			if diff == 0 {
+				continue
+			}
+			entry := WalletTransaction{
+				UserID:     r.ID,
+				Amount:     diff,
+				SaldoAfter: diff, // saldo sebelum transaksi ledger pertama
+				Type:       WalletAdjustment,
+				Note:       "saldo awal",
+				CreatedAt:  r.CreatedAt,
+			}
+			if err := tx.Create(&entry).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// backfillCashPaid order tunai yang sudah sampai sebelum status bayar ikut
+// diperbarui masih "pending" → tandai paid (uang diterima saat diserahkan).
+// Hanya menyentuh cash + sampai + pending, aman dijalankan berulang.
+func backfillCashPaid(db *gorm.DB) error {
+	return db.Model(&Transaksi{}).
+		Where("payment_method = ? AND status = ? AND payment_status = ?", PaymentCash, StatusSampai, PaymentPending).
+		UpdateColumn("payment_status", PaymentPaid).Error
+}
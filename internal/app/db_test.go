@@ -0,0 +1,73 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+func TestBackfillWalletLedger(t *testing.T) {
+	db := apptest.OpenDB(t)
+
+	// saldo lama ditulis langsung tanpa ledger
+	legacy, _ := apptest.SeedSiswa(t, db, 0)
+	db.Model(&app.User{}).Where("id = ?", legacy.ID).UpdateColumn("saldo", 50000)
+
+	// sebagian lewat ledger, sebagian langsung
+	mixed, _ := apptest.SeedSiswa(t, db, 10000)
+	db.Model(&app.User{}).Where("id = ?", mixed.ID).UpdateColumn("saldo", 15000)
+
+	// sudah konsisten → tidak disentuh
+	clean, _ := apptest.SeedSiswa(t, db, 20000)
+
+	for run := 1; run <= 2; run++ {
+		if err := app.BackfillWalletLedger(db); err != nil {
+			t.Fatalf("run %d: %v", run, err)
+		}
+	}
+
+	cases := []struct {
+		name    string
+		userID  uint
+		saldo   float64
+		awal    float64 // 0 = tidak ada entri saldo awal
+		entries int64
+	}{
+		{"legacy", legacy.ID, 50000, 50000, 1},
+		{"mixed", mixed.ID, 15000, 5000, 2},
+		{"clean", clean.ID, 20000, 0, 1},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ledger, err := app.LedgerSaldo(db, tc.userID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ledger != tc.saldo {
+				t.Errorf("ledger = %v, want %v", ledger, tc.saldo)
+			}
+
+			var n int64
+			db.Model(&app.WalletTransaction{}).Where("user_id = ?", tc.userID).Count(&n)
+			if n != tc.entries {
+				t.Errorf("entries = %d, want %d (backfill tidak idempoten?)", n, tc.entries)
+			}
+
+			var awal []app.WalletTransaction
+			db.Where("user_id = ? AND note = ?", tc.userID, "saldo awal").Find(&awal)
+			if tc.awal == 0 {
+				if len(awal) != 0 {
+					t.Errorf("unexpected saldo awal entry: %+v", awal)
+				}
+				return
+			}
+			if len(awal) != 1 {
+				t.Fatalf("saldo awal entries = %d, want 1", len(awal))
+			}
+			if awal[0].Type != app.WalletAdjustment || awal[0].Amount != tc.awal {
+				t.Errorf("saldo awal = %s %v, want adjustment %v", awal[0].Type, awal[0].Amount, tc.awal)
+			}
+		})
+	}
+}
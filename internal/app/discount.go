@@ -0,0 +1,328 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =========================
+// DISCOUNT ENGINE
+// =========================
+//
+// Satu-satunya tempat menghitung harga setelah diskon. Dipakai oleh
+// preview harga menu (SiswaListMenus / SiswaGetMenu) dan harga final
+// order (SiswaCreateOrder), jadi angka yang dilihat siswa = yang dibayar.
+//
+// Diskon berlaku untuk 1 item jika:
+//   - tanggal aktif (tanggal_awal / tanggal_akhir)
+//   - jam sekarang (WIB) di dalam jam_mulai–jam_selesai (jika diisi;
+//     boleh melewati tengah malam, mis. 22:00–02:00)
+//   - menu masuk cakupan: daftar menu (jika ada) DAN jenis (jika ada)
+//   - minimal belanja terpenuhi: total harga normal semua item di
+//     keranjang yang masuk cakupan diskon tsb >= min_belanja
+//
+// Aturan jika beberapa diskon berlaku pada item yang sama:
+//  1. Dari diskon NON-stackable dipilih SATU yang potongannya paling besar
+//     (seri → prioritas lebih tinggi → diskon terbaru).
+//  2. Semua diskon STACKABLE lalu diterapkan di atas harga tsb, urut
+//     prioritas tertinggi dulu (persen dihitung dari harga sisa).
+//  3. Harga akhir tidak pernah di bawah 0.
+
+var ErrInvalidJam = errors.New("invalid jam, use HH:MM")
+
+// PriceLine 1 item keranjang yang akan dihitung harganya
+type PriceLine struct {
+	Menu Menu
+	Qty  int
+}
+
+// AppliedDiskon diskon yang terpakai pada 1 item (potongan per unit)
+type AppliedDiskon struct {
+	Diskon   Diskon
+	Potongan float64
+}
+
+// PricedLine hasil hitung 1 item
+type PricedLine struct {
+	Menu        Menu
+	Qty         int
+	HargaNormal float64 // per unit
+	HargaAkhir  float64 // per unit, setelah semua diskon
+	Applied     []AppliedDiskon
+}
+
+// Potongan total potongan per unit
+func (l PricedLine) Potongan() float64 { return Round2(l.HargaNormal - l.HargaAkhir) }
+
+// Persen potongan efektif (persen dari harga normal)
+func (l PricedLine) Persen() float64 {
+	if l.HargaNormal <= 0 {
+		return 0
+	}
+	return Round2(l.Potongan() / l.HargaNormal * 100)
+}
+
+// Primary diskon utama (pertama diterapkan), nil jika tidak ada
+func (l PricedLine) Primary() *Diskon {
+	if len(l.Applied) == 0 {
+		return nil
+	}
+	return &l.Applied[0].Diskon
+}
+
+// DiskonNames nama semua diskon terpakai, mis. "Happy Hour + Promo Minuman"
+// (maks 255 karakter, sesuai kolom snapshot)
+func (l PricedLine) DiskonNames() string {
+	names := make([]string, 0, len(l.Applied))
+	for _, a := range l.Applied {
+		names = append(names, a.Diskon.Nama)
+	}
+	joined := strings.Join(names, " + ")
+	if r := []rune(joined); len(r) > 255 {
+		joined = string(r[:255])
+	}
+	return joined
+}
+
+// =========================
+// RULE CHECKS
+// =========================
+
+// ParseJam membaca "HH:MM" → menit sejak 00:00
+func ParseJam(s string) (int, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(s))
+	if err != nil {
+		return 0, ErrInvalidJam
+	}
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// ActiveAt true jika tanggal & jam diskon mencakup waktu t
+func (d Diskon) ActiveAt(t time.Time) bool {
+	if d.TanggalAwal != nil && t.Before(*d.TanggalAwal) {
+		return false
+	}
+	if d.TanggalAkhir != nil && t.After(*d.TanggalAkhir) {
+		return false
+	}
+
+	if d.JamMulai == "" || d.JamSelesai == "" {
+		return true
+	}
+	start, err1 := ParseJam(d.JamMulai)
+	end, err2 := ParseJam(d.JamSelesai)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	wib := t.In(JakartaLoc())
+	now := wib.Hour()*60 + wib.Minute()
+	if start <= end {
+		return now >= start && now < end
+	}
+	// melewati tengah malam
+	return now >= start || now < end
+}
+
+// Covers true jika menu masuk cakupan diskon (stan, daftar menu, jenis)
+func (d Diskon) Covers(m Menu) bool {
+	if d.StanID != m.StanID {
+		return false
+	}
+	if d.Jenis != nil && *d.Jenis != m.Jenis {
+		return false
+	}
+	if len(d.Menus) == 0 {
+		return true
+	}
+	for _, dm := range d.Menus {
+		if dm.ID == m.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// cut potongan per unit untuk harga tertentu
+func (d Diskon) cut(price float64) float64 {
+	var c float64
+	switch d.Tipe {
+	case DiskonNominal:
+		c = d.Nominal
+	default:
+		c = price - ApplyDiscount(price, d.Persentase)
+	}
+	if c > price {
+		c = price
+	}
+	if c < 0 {
+		c = 0
+	}
+	return Round2(c)
+}
+
+// Label ringkas, mis. "10%" / "Rp 2.000"
+func (d Diskon) Label() string {
+	if d.Tipe == DiskonNominal {
+		return FormatRupiah(d.Nominal)
+	}
+	return fmt.Sprintf("%g%%", d.Persentase)
+}
+
+// =========================
+// LOAD & PRICE
+// =========================
+
+// LoadActiveDiscounts diskon stan yang aktif pada tanggal t (jam dicek di PriceLines)
+func LoadActiveDiscounts(db *gorm.DB, stanID uint, t time.Time) ([]Diskon, error) {
+	var ds []Diskon
+	err := db.
+		Preload("Menus").
+		Where(
+			"stan_id = ? AND (tanggal_awal IS NULL OR tanggal_awal <= ?) AND (tanggal_akhir IS NULL OR tanggal_akhir >= ?)",
+			stanID, t.UTC(), t.UTC(),
+		).
+		Order("prioritas DESC, created_at DESC").
+		Find(&ds).Error
+	return ds, err
+}
+
+// LoadActiveDiscountsForStans seperti LoadActiveDiscounts untuk banyak stan (1 query)
+func LoadActiveDiscountsForStans(db *gorm.DB, stanIDs []uint, t time.Time) (map[uint][]Diskon, error) {
+	out := map[uint][]Diskon{}
+	if len(stanIDs) == 0 {
+		return out, nil
+	}
+
+	var ds []Diskon
+	err := db.
+		Preload("Menus").
+		Where(
+			"stan_id IN ? AND (tanggal_awal IS NULL OR tanggal_awal <= ?) AND (tanggal_akhir IS NULL OR tanggal_akhir >= ?)",
+			stanIDs, t.UTC(), t.UTC(),
+		).
+		Order("prioritas DESC, created_at DESC").
+		Find(&ds).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range ds {
+		out[d.StanID] = append(out[d.StanID], d)
+	}
+	return out, nil
+}
+
+// PriceLines menghitung harga akhir tiap item keranjang.
+// discounts = hasil LoadActiveDiscounts untuk stan item-item tsb.
+func PriceLines(discounts []Diskon, lines []PriceLine, t time.Time) []PricedLine {
+	// diskon yang aktif jam ini
+	active := make([]Diskon, 0, len(discounts))
+	for _, d := range discounts {
+		if d.ActiveAt(t) {
+			active = append(active, d)
+		}
+	}
+
+	// minimal belanja: total harga normal item yang masuk cakupan
+	eligible := make([]bool, len(active))
+	for i, d := range active {
+		var spend float64
+		for _, l := range lines {
+			if d.Covers(l.Menu) {
+				spend += float64(l.Qty) * Round2(l.Menu.Harga)
+			}
+		}
+		eligible[i] = spend > 0 && spend >= d.MinBelanja
+	}
+
+	out := make([]PricedLine, 0, len(lines))
+	for _, l := range lines {
+		normal := Round2(l.Menu.Harga)
+		pl := PricedLine{Menu: l.Menu, Qty: l.Qty, HargaNormal: normal, HargaAkhir: normal}
+
+		var best *Diskon
+		var bestCut float64
+		var stack []Diskon
+		for i, d := range active {
+			if !eligible[i] || !d.Covers(l.Menu) {
+				continue
+			}
+			if d.Stackable {
+				stack = append(stack, d)
+				continue
+			}
+			// active sudah urut prioritas DESC, created_at DESC → seri dimenangkan yang lebih dulu
+			if c := d.cut(normal); best == nil || c > bestCut {
+				best, bestCut = &active[i], c
+			}
+		}
+
+		if best != nil && bestCut > 0 {
+			pl.HargaAkhir = Round2(pl.HargaAkhir - bestCut)
+			pl.Applied = append(pl.Applied, AppliedDiskon{Diskon: *best, Potongan: bestCut})
+		}
+
+		sort.SliceStable(stack, func(i, j int) bool { return stack[i].Prioritas > stack[j].Prioritas })
+		for _, d := range stack {
+			c := d.cut(pl.HargaAkhir)
+			if c <= 0 {
+				continue
+			}
+			pl.HargaAkhir = Round2(pl.HargaAkhir - c)
+			pl.Applied = append(pl.Applied, AppliedDiskon{Diskon: d, Potongan: c})
+		}
+
+		out = append(out, pl)
+	}
+	return out
+}
+
+// PreviewMenuPrice harga 1 unit menu (tanpa keranjang) — untuk daftar menu.
+// Diskon dengan min_belanja di atas harga menu tidak ikut di preview.
+func PreviewMenuPrice(discounts []Diskon, m Menu, t time.Time) PricedLine {
+	return PriceLines(discounts, []PriceLine{{Menu: m, Qty: 1}}, t)[0]
+}
+
+// DiscountCache memuat diskon aktif sekali per stan (mis. untuk list menu)
+type DiscountCache struct {
+	db    *gorm.DB
+	at    time.Time
+	byStn map[uint][]Diskon
+}
+
+func NewDiscountCache(db *gorm.DB, t time.Time) *DiscountCache {
+	return &DiscountCache{db: db, at: t, byStn: map[uint][]Diskon{}}
+}
+
+// Load memuat diskon beberapa stan sekaligus (1 query untuk yang belum ada di cache)
+func (c *DiscountCache) Load(stanIDs []uint) error {
+	missing := make([]uint, 0, len(stanIDs))
+	for _, id := range stanIDs {
+		if _, ok := c.byStn[id]; !ok {
+			missing = append(missing, id)
+		}
+	}
+	byStan, err := LoadActiveDiscountsForStans(c.db, missing, c.at)
+	if err != nil {
+		return err
+	}
+	for _, id := range missing {
+		c.byStn[id] = byStan[id] // nil = stan tanpa diskon (tetap di-cache)
+	}
+	return nil
+}
+
+// Preview harga 1 unit menu (diskon stan dimuat sekali)
+func (c *DiscountCache) Preview(m Menu) PricedLine {
+	ds, ok := c.byStn[m.StanID]
+	if !ok {
+		ds, _ = LoadActiveDiscounts(c.db, m.StanID, c.at)
+		c.byStn[m.StanID] = ds
+	}
+	return PreviewMenuPrice(ds, m, c.at)
+}
@@ -0,0 +1,223 @@
+package app_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+const testStan = 1
+
+var (
+	nasi  = app.Menu{ID: 1, StanID: testStan, NamaMakanan: "Nasi Goreng", Harga: 20000, Jenis: app.JenisMakanan}
+	mie   = app.Menu{ID: 2, StanID: testStan, NamaMakanan: "Mie Ayam", Harga: 30000, Jenis: app.JenisMakanan}
+	jeruk = app.Menu{ID: 3, StanID: testStan, NamaMakanan: "Es Jeruk", Harga: 15000, Jenis: app.JenisMinuman}
+)
+
+func persen(nama string, p float64) app.Diskon {
+	return app.Diskon{StanID: testStan, Nama: nama, Tipe: app.DiskonPersen, Persentase: p}
+}
+
+func nominal(nama string, n float64) app.Diskon {
+	return app.Diskon{StanID: testStan, Nama: nama, Tipe: app.DiskonNominal, Nominal: n}
+}
+
+func with(d app.Diskon, fn func(*app.Diskon)) app.Diskon {
+	fn(&d)
+	return d
+}
+
+// wib jam tertentu hari ini (WIB)
+func wib(hour, min int) time.Time {
+	now := time.Now().In(app.JakartaLoc())
+	return time.Date(now.Year(), now.Month(), now.Day(), hour, min, 0, 0, app.JakartaLoc())
+}
+
+func TestPriceLines(t *testing.T) {
+	minuman := app.JenisMinuman
+	noon := wib(12, 0)
+
+	cases := []struct {
+		name      string
+		discounts []app.Diskon // urut seperti LoadActiveDiscounts (prioritas DESC)
+		lines     []app.PriceLine
+		at        time.Time
+		want      []float64 // harga akhir per unit, per line
+		names     []string  // DiskonNames per line
+	}{
+		{
+			name:  "tanpa diskon",
+			lines: []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:    noon,
+			want:  []float64{20000},
+			names: []string{""},
+		},
+		{
+			name:      "non-stackable terbesar dipilih",
+			discounts: []app.Diskon{persen("Promo 10%", 10), nominal("Potong 3rb", 3000), persen("Promo 5%", 5)},
+			lines:     []app.PriceLine{{Menu: nasi, Qty: 1}, {Menu: mie, Qty: 1}},
+			at:        noon,
+			// nasi: 10% = 2.000 < 3.000; mie: 10% = 3.000 seri → yang lebih dulu (prioritas)
+			want:  []float64{17000, 27000},
+			names: []string{"Potong 3rb", "Promo 10%"},
+		},
+		{
+			name: "stackable urut prioritas di atas non-stackable",
+			discounts: []app.Diskon{
+				with(persen("Setengah", 50), func(d *app.Diskon) { d.Stackable = true; d.Prioritas = 5 }),
+				persen("Promo 10%", 10),
+				with(nominal("Potong 1rb", 1000), func(d *app.Diskon) { d.Stackable = true; d.Prioritas = 1 }),
+			},
+			lines: []app.PriceLine{{Menu: nasi, Qty: 2}},
+			at:    noon,
+			// 20.000 → 18.000 (10%) → 9.000 (50%, prio 5) → 8.000 (1rb, prio 1)
+			want:  []float64{8000},
+			names: []string{"Promo 10% + Setengah + Potong 1rb"},
+		},
+		{
+			name:      "stackable tidak membuat harga negatif",
+			discounts: []app.Diskon{nominal("Potong 18rb", 18000), with(nominal("Potong 5rb", 5000), func(d *app.Diskon) { d.Stackable = true })},
+			lines:     []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:        noon,
+			want:      []float64{0},
+			names:     []string{"Potong 18rb + Potong 5rb"},
+		},
+		{
+			name: "min belanja dari item yang dicakup saja (tidak terpenuhi)",
+			discounts: []app.Diskon{with(persen("Minuman 20%", 20), func(d *app.Diskon) {
+				d.Jenis = &minuman
+				d.MinBelanja = 20000
+			})},
+			// total keranjang 45.000 tapi minuman hanya 15.000
+			lines: []app.PriceLine{{Menu: mie, Qty: 1}, {Menu: jeruk, Qty: 1}},
+			at:    noon,
+			want:  []float64{30000, 15000},
+			names: []string{"", ""},
+		},
+		{
+			name: "min belanja dari item yang dicakup saja (terpenuhi)",
+			discounts: []app.Diskon{with(persen("Minuman 20%", 20), func(d *app.Diskon) {
+				d.Jenis = &minuman
+				d.MinBelanja = 20000
+			})},
+			lines: []app.PriceLine{{Menu: mie, Qty: 1}, {Menu: jeruk, Qty: 2}},
+			at:    noon,
+			want:  []float64{30000, 12000},
+			names: []string{"", "Minuman 20%"},
+		},
+		{
+			name: "diskon per menu",
+			discounts: []app.Diskon{with(nominal("Mie Hemat", 5000), func(d *app.Diskon) {
+				d.Menus = []app.Menu{mie}
+			})},
+			lines: []app.PriceLine{{Menu: nasi, Qty: 1}, {Menu: mie, Qty: 1}},
+			at:    noon,
+			want:  []float64{20000, 25000},
+			names: []string{"", "Mie Hemat"},
+		},
+		{
+			name:      "happy hour WIB (di dalam jam)",
+			discounts: []app.Diskon{with(persen("Happy Hour", 25), func(d *app.Diskon) { d.JamMulai, d.JamSelesai = "14:00", "16:00" })},
+			lines:     []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:        wib(15, 30).UTC(), // 08:30 UTC
+			want:      []float64{15000},
+			names:     []string{"Happy Hour"},
+		},
+		{
+			name:      "happy hour WIB (jam selesai eksklusif)",
+			discounts: []app.Diskon{with(persen("Happy Hour", 25), func(d *app.Diskon) { d.JamMulai, d.JamSelesai = "14:00", "16:00" })},
+			lines:     []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:        wib(16, 0).UTC(),
+			want:      []float64{20000},
+			names:     []string{""},
+		},
+		{
+			name:      "happy hour melewati tengah malam",
+			discounts: []app.Diskon{with(persen("Malam", 50), func(d *app.Diskon) { d.JamMulai, d.JamSelesai = "22:00", "02:00" })},
+			lines:     []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:        wib(1, 15),
+			want:      []float64{10000},
+			names:     []string{"Malam"},
+		},
+		{
+			name: "periode tanggal sudah lewat",
+			discounts: []app.Diskon{with(persen("Kemarin", 50), func(d *app.Diskon) {
+				end := noon.Add(-24 * time.Hour)
+				d.TanggalAkhir = &end
+			})},
+			lines: []app.PriceLine{{Menu: nasi, Qty: 1}},
+			at:    noon,
+			want:  []float64{20000},
+			names: []string{""},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := app.PriceLines(tc.discounts, tc.lines, tc.at)
+			if len(got) != len(tc.lines) {
+				t.Fatalf("lines = %d, want %d", len(got), len(tc.lines))
+			}
+			for i, pl := range got {
+				if pl.HargaAkhir != tc.want[i] {
+					t.Errorf("line %d (%s): harga akhir = %v, want %v", i, pl.Menu.NamaMakanan, pl.HargaAkhir, tc.want[i])
+				}
+				if pl.DiskonNames() != tc.names[i] {
+					t.Errorf("line %d (%s): diskon = %q, want %q", i, pl.Menu.NamaMakanan, pl.DiskonNames(), tc.names[i])
+				}
+				if pl.HargaNormal != tc.lines[i].Menu.Harga {
+					t.Errorf("line %d: harga normal = %v, want %v", i, pl.HargaNormal, tc.lines[i].Menu.Harga)
+				}
+			}
+		})
+	}
+}
+
+// Harga yang dilihat siswa di daftar menu (DiscountCache.Preview) sama dengan
+// yang dibayar saat order (LoadActiveDiscounts + PriceLines, seperti order builder).
+func TestPreviewMatchesOrderTotal(t *testing.T) {
+	db := apptest.OpenDB(t)
+	stan := apptest.SeedStan(t, db, "Kantin Preview")
+	menu := apptest.SeedMenu(t, db, stan.ID, "Ayam Geprek", 17500)
+	other := apptest.SeedMenu(t, db, stan.ID, "Tahu", 3000)
+
+	for _, d := range []app.Diskon{
+		{StanID: stan.ID, Nama: "Promo 15%", Tipe: app.DiskonPersen, Persentase: 15, Prioritas: 2},
+		{StanID: stan.ID, Nama: "Potong 2rb", Tipe: app.DiskonNominal, Nominal: 2000, Prioritas: 1},
+		{StanID: stan.ID, Nama: "Member", Tipe: app.DiskonPersen, Persentase: 10, Stackable: true},
+		{StanID: stan.ID, Nama: "Tahu Saja", Tipe: app.DiskonNominal, Nominal: 500, Menus: []app.Menu{*other}},
+	} {
+		if err := db.Create(&d).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	now := time.Now()
+	cache := app.NewDiscountCache(db, now)
+	preview := cache.Preview(*menu)
+
+	discounts, err := app.LoadActiveDiscounts(db, stan.ID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const qty = 3
+	priced := app.PriceLines(discounts, []app.PriceLine{{Menu: *menu, Qty: qty}}, now)
+
+	var orderTotal float64
+	for _, pl := range priced {
+		orderTotal += float64(pl.Qty) * pl.HargaAkhir
+	}
+
+	// 17.500 - 15% (2.625) = 14.875 → member 10% (1.487,5) = 13.387,5
+	if preview.HargaAkhir != 13387.5 {
+		t.Errorf("preview = %v, want 13387.5", preview.HargaAkhir)
+	}
+	if want := app.Round2(preview.HargaAkhir * qty); app.Round2(orderTotal) != want {
+		t.Errorf("order total = %v, want preview × qty = %v", orderTotal, want)
+	}
+	if preview.DiskonNames() != priced[0].DiskonNames() {
+		t.Errorf("diskon preview %q != order %q", preview.DiskonNames(), priced[0].DiskonNames())
+	}
+}
@@ -0,0 +1,301 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =========================
+// REALTIME EVENTS (SSE HUB)
+// =========================
+//
+// Pub/sub in-process (1 instance server). Topic:
+// - "siswa:<siswa_id>" → order milik 1 siswa
+// - "stan:<stan_id>"   → order masuk ke 1 stan
+//
+// Setiap topic menyimpan N event terakhir supaya client yang reconnect
+// dengan Last-Event-ID bisa menerima event yang terlewat. Topic tanpa
+// subscriber yang tidak menerima event selama eventHistoryTTL dibuang
+// supaya map history tidak tumbuh terus (1 topic per siswa / stan).
+
+const (
+	eventHistorySize  = 100              // event terakhir per topic (untuk replay)
+	eventHistoryTTL   = 30 * time.Minute // topic idle tanpa subscriber → history dibuang
+	historySweepEvery = time.Minute      // jeda minimal antar sweep topic idle
+	subscriberBuffer  = 32               // buffer per subscriber
+	sseHeartbeatEvery = 15 * time.Second // komentar ": ping" supaya proxy tidak memutus
+	sseRetryMillis    = 3000             // saran jeda reconnect ke browser
+)
+
+// Event = 1 pesan SSE
+type Event struct {
+	ID   uint64      `json:"id"`
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// Subscriber = 1 koneksi SSE
+type Subscriber struct {
+	topic string
+	ch    chan Event
+	done  chan struct{}
+	once  sync.Once
+}
+
+// C channel event untuk subscriber ini
+func (s *Subscriber) C() <-chan Event { return s.ch }
+
+// Done tertutup jika subscriber diputus hub (buffer penuh / client lambat)
+func (s *Subscriber) Done() <-chan struct{} { return s.done }
+
+func (s *Subscriber) close() {
+	s.once.Do(func() { close(s.done) })
+}
+
+// topicHistory event terakhir 1 topic + waktu event terakhir (untuk TTL)
+type topicHistory struct {
+	events []Event
+	last   time.Time
+}
+
+// Hub pub/sub in-memory
+type Hub struct {
+	mu      sync.Mutex
+	nextID  uint64
+	subs    map[string]map[*Subscriber]struct{}
+	history map[string]*topicHistory
+
+	now       func() time.Time // diganti di test
+	lastSweep time.Time
+	// ID event terbaru yang ikut terbuang saat topic idle dievict;
+	// client dengan Last-Event-ID di bawahnya perlu resync
+	evictedMax uint64
+}
+
+// NewHub membuat hub baru. ID event dimulai dari unix millis supaya tetap
+// naik walau server restart (Last-Event-ID lama tidak "melompati" event baru).
+func NewHub() *Hub {
+	return &Hub{
+		nextID:  uint64(time.Now().UnixMilli()),
+		subs:    map[string]map[*Subscriber]struct{}{},
+		history: map[string]*topicHistory{},
+		now:     time.Now,
+	}
+}
+
+// Events hub global (dipakai handler order & stream)
+var Events = NewHub()
+
+// Publish mengirim event ke semua subscriber topic (non-blocking).
+// Subscriber yang buffernya penuh diputus; client akan reconnect
+// dan mengejar ketertinggalan lewat Last-Event-ID.
+func (h *Hub) Publish(topic, typ string, data interface{}) Event {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	now := h.now()
+	h.sweepIdleLocked(now)
+
+	h.nextID++
+	ev := Event{ID: h.nextID, Type: typ, Data: data}
+
+	th := h.history[topic]
+	if th == nil {
+		th = &topicHistory{}
+		h.history[topic] = th
+	}
+	th.events = append(th.events, ev)
+	if len(th.events) > eventHistorySize {
+		th.events = th.events[len(th.events)-eventHistorySize:]
+	}
+	th.last = now
+
+	for s := range h.subs[topic] {
+		select {
+		case s.ch <- ev:
+		default:
+			delete(h.subs[topic], s)
+			s.close()
+		}
+	}
+	return ev
+}
+
+// sweepIdleLocked membuang history topic yang tidak punya subscriber dan
+// event terakhirnya lebih tua dari eventHistoryTTL. Dijalankan paling
+// sering 1x per historySweepEvery. h.mu harus sudah di-lock.
+func (h *Hub) sweepIdleLocked(now time.Time) {
+	if now.Sub(h.lastSweep) < historySweepEvery {
+		return
+	}
+	h.lastSweep = now
+
+	for topic, th := range h.history {
+		if len(h.subs[topic]) > 0 || now.Sub(th.last) < eventHistoryTTL {
+			continue
+		}
+		if n := len(th.events); n > 0 && th.events[n-1].ID > h.evictedMax {
+			h.evictedMax = th.events[n-1].ID
+		}
+		delete(h.history, topic)
+	}
+}
+
+// Subscribe mendaftarkan subscriber baru. Jika lastID > 0, event dengan
+// ID > lastID yang masih tersimpan dikembalikan sebagai backlog.
+// resync = true jika sebagian event sudah tidak tersimpan (client sebaiknya fetch ulang).
+func (h *Hub) Subscribe(topic string, lastID uint64) (sub *Subscriber, backlog []Event, resync bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	sub = &Subscriber{
+		topic: topic,
+		ch:    make(chan Event, subscriberBuffer),
+		done:  make(chan struct{}),
+	}
+	if h.subs[topic] == nil {
+		h.subs[topic] = map[*Subscriber]struct{}{}
+	}
+	h.subs[topic][sub] = struct{}{}
+
+	if lastID > 0 {
+		var hist []Event
+		if th := h.history[topic]; th != nil {
+			hist = th.events
+		}
+		for _, ev := range hist {
+			if ev.ID > lastID {
+				backlog = append(backlog, ev)
+			}
+		}
+		// event tertua yang tersimpan sudah lebih baru dari lastID+1
+		// → ada event yang terbuang dari history
+		if len(hist) == eventHistorySize && hist[0].ID > lastID+1 {
+			resync = true
+		}
+		// history topic mungkin sudah dievict (idle) sejak lastID
+		if lastID < h.evictedMax && (len(hist) == 0 || hist[0].ID > lastID+1) {
+			resync = true
+		}
+	}
+	return sub, backlog, resync
+}
+
+// Unsubscribe melepas subscriber dari hub
+func (h *Hub) Unsubscribe(sub *Subscriber) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if m := h.subs[sub.topic]; m != nil {
+		delete(m, sub)
+		if len(m) == 0 {
+			delete(h.subs, sub.topic)
+		}
+	}
+	sub.close()
+}
+
+// =========================
+// TOPIC & ORDER EVENT HELPERS
+// =========================
+
+// TopicSiswa topic order milik siswa
+func TopicSiswa(siswaID uint) string { return fmt.Sprintf("siswa:%d", siswaID) }
+
+// TopicStan topic order masuk ke stan
+func TopicStan(stanID uint) string { return fmt.Sprintf("stan:%d", stanID) }
+
+// Tipe event order
+const (
+	EventOrderCreated   = "order.created"
+	EventOrderStatus    = "order.status"
+	EventOrderCancelled = "order.cancelled"
+)
+
+// PublishOrderEvent memberi tahu siswa pemilik & stan tujuan.
+// Panggil SETELAH tx.Commit() berhasil.
+func PublishOrderEvent(trx *Transaksi, typ string) {
+	data := map[string]interface{}{
+		"transaksi_id":   trx.PublicID,
+		"status":         trx.Status,
+		"payment_method": trx.PaymentMethod,
+		"payment_status": trx.PaymentStatus,
+		"cancel_reason":  trx.CancelReason,
+		"updated_at":     time.Now().UTC().Format(time.RFC3339),
+	}
+	Events.Publish(TopicSiswa(trx.SiswaID), typ, data)
+	Events.Publish(TopicStan(trx.StanID), typ, data)
+}
+
+// =========================
+// SSE HANDLER
+// =========================
+
+// ServeSSE men-stream event topic ke client sampai koneksi ditutup.
+// Mendukung reconnect: header Last-Event-ID (atau ?last_event_id=).
+func ServeSSE(c *gin.Context, topic string) {
+	lastRaw := c.GetHeader("Last-Event-ID")
+	if lastRaw == "" {
+		lastRaw = c.Query("last_event_id")
+	}
+	lastID, _ := strconv.ParseUint(lastRaw, 10, 64)
+
+	flusher, ok := c.Writer.(http.Flusher)
+	if !ok {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "streaming not supported"})
+		return
+	}
+
+	sub, backlog, resync := Events.Subscribe(topic, lastID)
+	defer Events.Unsubscribe(sub)
+
+	h := c.Writer.Header()
+	h.Set("Content-Type", "text/event-stream")
+	h.Set("Cache-Control", "no-cache")
+	h.Set("Connection", "keep-alive")
+	h.Set("X-Accel-Buffering", "no") // nginx: jangan buffer
+	c.Status(http.StatusOK)
+
+	fmt.Fprintf(c.Writer, "retry: %d\n\n", sseRetryMillis)
+	if resync {
+		fmt.Fprintf(c.Writer, "event: resync\ndata: {}\n\n")
+	}
+	for _, ev := range backlog {
+		writeSSE(c, ev)
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(sseHeartbeatEvery)
+	defer heartbeat.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-sub.Done():
+			// diputus hub (client terlalu lambat) → client reconnect
+			return
+		case ev := <-sub.C():
+			writeSSE(c, ev)
+			flusher.Flush()
+		case <-heartbeat.C:
+			fmt.Fprint(c.Writer, ": ping\n\n")
+			flusher.Flush()
+		}
+	}
+}
+
+func writeSSE(c *gin.Context, ev Event) {
+	b, err := json.Marshal(ev.Data)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", ev.ID, ev.Type, b)
+}
@@ -0,0 +1,42 @@
+package app_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+func TestHubEvictsIdleTopics(t *testing.T) {
+	now := time.Date(2025, 3, 10, 8, 0, 0, 0, app.JakartaLoc())
+	h := app.NewHub()
+	h.SetClock(func() time.Time { return now })
+
+	old := h.Publish("siswa:1", app.EventOrderCreated, nil)
+	h.Publish("stan:1", app.EventOrderCreated, nil)
+
+	// stan:1 masih didengar → tidak boleh dibuang walau idle
+	sub, _, _ := h.Subscribe("stan:1", 0)
+	defer h.Unsubscribe(sub)
+
+	now = now.Add(31 * time.Minute)
+	h.Publish("siswa:2", app.EventOrderCreated, nil)
+
+	if got := h.HistoryTopics(); got != 2 {
+		t.Fatalf("topics = %d, want 2 (siswa:1 dievict)", got)
+	}
+
+	// client siswa:1 yang reconnect tidak bisa di-replay → resync
+	s1, backlog, resync := h.Subscribe("siswa:1", old.ID-1)
+	defer h.Unsubscribe(s1)
+	if len(backlog) != 0 || !resync {
+		t.Fatalf("backlog = %d resync = %v, want 0 / true", len(backlog), resync)
+	}
+
+	// siswa:2 baru saja menerima event → tetap ada, replay normal
+	s2, backlog, resync := h.Subscribe("siswa:2", old.ID)
+	defer h.Unsubscribe(s2)
+	if len(backlog) != 1 || resync {
+		t.Fatalf("siswa:2 backlog = %d resync = %v, want 1 / false", len(backlog), resync)
+	}
+}
@@ -0,0 +1,16 @@
+package app
+
+import "time"
+
+// akses fungsi internal untuk test di package app_test
+var BackfillWalletLedger = backfillWalletLedger
+
+// SetClock mengganti sumber waktu hub (TTL history)
+func (h *Hub) SetClock(now func() time.Time) { h.now = now }
+
+// HistoryTopics jumlah topic yang history-nya masih tersimpan
+func (h *Hub) HistoryTopics() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.history)
+}
@@ -0,0 +1,227 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+
+	// decoder tambahan (jpeg sudah di-import di atas)
+	_ "image/png"
+
+	_ "golang.org/x/image/webp"
+)
+
+// =========================
+// MENU IMAGE
+// =========================
+//
+// Upload gambar menu → divalidasi (content type dari isi file, bukan header;
+// ukuran; dimensi) → di-decode → disimpan ulang sebagai JPEG:
+//   - full  : sisi terpanjang maks 1200px
+//   - thumb : 320×320 (crop tengah), untuk list menu
+// Re-encode sekaligus membuang metadata (EXIF/GPS) dari foto HP.
+
+const (
+	menuImageMaxSide   = 1200
+	menuThumbSide      = 320
+	menuImageMaxPixels = 40_000_000 // tolak "decompression bomb"
+	menuImageQuality   = 85
+)
+
+var (
+	ErrImageTooLarge    = errors.New("image too large")
+	ErrImageUnsupported = errors.New("unsupported image type, use jpeg|png|webp")
+	ErrImageInvalid     = errors.New("invalid image")
+)
+
+// allowedImageTypes hasil http.DetectContentType yang diterima
+var allowedImageTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// MenuImageMaxBytes batas ukuran upload (env MENU_IMAGE_MAX_MB, default 5)
+func MenuImageMaxBytes() int64 {
+	mb, err := strconv.Atoi(os.Getenv("MENU_IMAGE_MAX_MB"))
+	if err != nil || mb <= 0 {
+		mb = 5
+	}
+	return int64(mb) << 20
+}
+
+// MenuImage hasil proses upload
+type MenuImage struct {
+	Full   []byte
+	Thumb  []byte
+	Width  int
+	Height int
+}
+
+// ProcessMenuImage validasi + resize + buat thumbnail
+func ProcessMenuImage(data []byte) (*MenuImage, error) {
+	if int64(len(data)) > MenuImageMaxBytes() {
+		return nil, ErrImageTooLarge
+	}
+	if !allowedImageTypes[http.DetectContentType(data)] {
+		return nil, ErrImageUnsupported
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > menuImageMaxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrImageInvalid
+	}
+
+	full := resizeFit(src, menuImageMaxSide)
+	thumb := resizeCover(src, menuThumbSide)
+
+	fullJPG, err := encodeJPEG(full)
+	if err != nil {
+		return nil, err
+	}
+	thumbJPG, err := encodeJPEG(thumb)
+	if err != nil {
+		return nil, err
+	}
+
+	b := full.Bounds()
+	return &MenuImage{Full: fullJPG, Thumb: thumbJPG, Width: b.Dx(), Height: b.Dy()}, nil
+}
+
+// resizeFit mengecilkan (tidak memperbesar) sampai sisi terpanjang <= max
+func resizeFit(src image.Image, max int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= max && h <= max {
+		return flatten(src)
+	}
+	if w >= h {
+		h = h * max / w
+		w = max
+	} else {
+		w = w * max / h
+		h = max
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+
+	dst := newWhiteRGBA(w, h)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Over, nil)
+	return dst
+}
+
+// resizeCover crop tengah jadi persegi lalu resize ke side×side
+func resizeCover(src image.Image, side int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	crop := b
+	if w > h {
+		off := (w - h) / 2
+		crop = image.Rect(b.Min.X+off, b.Min.Y, b.Min.X+off+h, b.Max.Y)
+	} else if h > w {
+		off := (h - w) / 2
+		crop = image.Rect(b.Min.X, b.Min.Y+off, b.Max.X, b.Min.Y+off+w)
+	}
+
+	if crop.Dx() < side {
+		side = crop.Dx()
+	}
+	dst := newWhiteRGBA(side, side)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, crop, draw.Over, nil)
+	return dst
+}
+
+// flatten gambar transparan (PNG/WebP) di atas latar putih (JPEG tanpa alpha)
+func flatten(src image.Image) image.Image {
+	b := src.Bounds()
+	dst := newWhiteRGBA(b.Dx(), b.Dy())
+	draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Over)
+	return dst
+}
+
+func newWhiteRGBA(w, h int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(dst, dst.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	return dst
+}
+
+func encodeJPEG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: menuImageQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// =========================
+// MENU ↔ STORAGE
+// =========================
+
+// SaveMenuImage menyimpan gambar + thumbnail; return key keduanya.
+// Nama file acak supaya URL lama tidak ter-cache saat gambar diganti.
+func SaveMenuImage(ctx context.Context, m Menu, img *MenuImage) (imageKey, thumbKey string, err error) {
+	name := strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	imageKey = fmt.Sprintf("menus/%s/%s.jpg", m.PublicID, name)
+	thumbKey = fmt.Sprintf("menus/%s/%s_thumb.jpg", m.PublicID, name)
+
+	if err := Files.Put(ctx, imageKey, img.Full, "image/jpeg"); err != nil {
+		return "", "", err
+	}
+	if err := Files.Put(ctx, thumbKey, img.Thumb, "image/jpeg"); err != nil {
+		Files.Delete(ctx, imageKey)
+		return "", "", err
+	}
+	return imageKey, thumbKey, nil
+}
+
+// DeleteMenuImageFiles hapus file gambar menu (error diabaikan: file yatim
+// tidak merusak data, dan tidak boleh menggagalkan request)
+func DeleteMenuImageFiles(ctx context.Context, keys ...string) {
+	if Files == nil {
+		return
+	}
+	for _, k := range keys {
+		if k != "" {
+			Files.Delete(ctx, k)
+		}
+	}
+}
+
+// ImageURL URL gambar penuh ("" jika belum ada)
+func (m Menu) ImageURL() string {
+	if m.ImageKey == "" || Files == nil {
+		return ""
+	}
+	return Files.URL(m.ImageKey)
+}
+
+// ThumbURL URL thumbnail ("" jika belum ada)
+func (m Menu) ThumbURL() string {
+	if m.ThumbKey == "" || Files == nil {
+		return ""
+	}
+	return Files.URL(m.ThumbKey)
+}
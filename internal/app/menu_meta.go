@@ -0,0 +1,150 @@
+package app
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// =========================
+// ALERGEN, TAG, KATEGORI
+// =========================
+//
+// Alergen: daftar tetap (AllAlergen), disimpan di Menu.Alergen sebagai
+// "kacang,susu" (urut sesuai AllAlergen) → filter SQL pakai FIND_IN_SET.
+// Tag: bebas, dinormalisasi jadi lowercase "a-z0-9-" (mis. "halal-certified").
+
+type Alergen string
+
+const (
+	AlergenKacang  Alergen = "kacang"
+	AlergenSusu    Alergen = "susu"
+	AlergenSeafood Alergen = "seafood"
+	AlergenGluten  Alergen = "gluten"
+)
+
+var AllAlergen = []Alergen{AlergenKacang, AlergenSusu, AlergenSeafood, AlergenGluten}
+
+const MaxTagsPerMenu = 10
+
+var tagRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,29}$`)
+
+// ParseAlergen validasi + normalisasi daftar alergen → nilai kolom
+func ParseAlergen(list []string) (string, error) {
+	want := map[Alergen]bool{}
+	for _, raw := range list {
+		a := Alergen(strings.ToLower(strings.TrimSpace(raw)))
+		if a == "" {
+			continue
+		}
+		if !IsValidAlergen(a) {
+			return "", fmt.Errorf("invalid alergen %q, use kacang|susu|seafood|gluten", raw)
+		}
+		want[a] = true
+	}
+
+	out := make([]string, 0, len(want))
+	for _, a := range AllAlergen {
+		if want[a] {
+			out = append(out, string(a))
+		}
+	}
+	return strings.Join(out, ","), nil
+}
+
+func IsValidAlergen(a Alergen) bool {
+	for _, x := range AllAlergen {
+		if x == a {
+			return true
+		}
+	}
+	return false
+}
+
+// AlergenList isi kolom alergen sebagai slice (selalu non-nil untuk JSON)
+func (m Menu) AlergenList() []string {
+	if m.Alergen == "" {
+		return []string{}
+	}
+	return strings.Split(m.Alergen, ",")
+}
+
+// NormalizeTag "Halal Certified" → "halal-certified"
+func NormalizeTag(s string) (string, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	t = strings.Join(strings.Fields(t), "-")
+	if !tagRe.MatchString(t) {
+		return "", fmt.Errorf("invalid tag %q (max 30 chars: a-z, 0-9, -)", s)
+	}
+	return t, nil
+}
+
+// NormalizeTags normalisasi + buang duplikat (urutan input dipertahankan)
+func NormalizeTags(list []string) ([]string, error) {
+	seen := map[string]bool{}
+	out := make([]string, 0, len(list))
+	for _, raw := range list {
+		if strings.TrimSpace(raw) == "" {
+			continue
+		}
+		t, err := NormalizeTag(raw)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[t] {
+			seen[t] = true
+			out = append(out, t)
+		}
+	}
+	if len(out) > MaxTagsPerMenu {
+		return nil, fmt.Errorf("max %d tags per menu", MaxTagsPerMenu)
+	}
+	return out, nil
+}
+
+// FindOrCreateTags ambil tag berdasarkan nama (buat yang belum ada)
+func FindOrCreateTags(db *gorm.DB, names []string) ([]Tag, error) {
+	tags := make([]Tag, 0, len(names))
+	for _, n := range names {
+		t := Tag{Nama: n}
+		if err := db.Where("nama = ?", n).FirstOrCreate(&t).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
+
+// TagNames nama tag menu (Tags harus di-preload)
+func (m Menu) TagNames() []string {
+	out := make([]string, 0, len(m.Tags))
+	for _, t := range m.Tags {
+		out = append(out, t.Nama)
+	}
+	return out
+}
+
+// =========================
+// FILTER QUERY (SISWA)
+// =========================
+
+// FilterMenusByTags semua tag harus ada pada menu (AND)
+func FilterMenusByTags(q *gorm.DB, tags []string) *gorm.DB {
+	for _, t := range tags {
+		q = q.Where(
+			"menus.id IN (SELECT menu_tags.menu_id FROM menu_tags JOIN tags ON tags.id = menu_tags.tag_id WHERE tags.nama = ?)",
+			t,
+		)
+	}
+	return q
+}
+
+// ExcludeMenusWithAlergen buang menu yang mengandung salah satu alergen
+func ExcludeMenusWithAlergen(q *gorm.DB, alergen []Alergen) *gorm.DB {
+	for _, a := range alergen {
+		q = q.Where("FIND_IN_SET(?, COALESCE(menus.alergen, '')) = 0", string(a))
+	}
+	return q
+}
@@ -0,0 +1,105 @@
+package app
+
+import (
+	"strings"
+	"time"
+	"unicode"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =========================
+// MENU SEARCH (SISWA)
+// =========================
+//
+// Pencarian nama & deskripsi pakai FULLTEXT index idx_menus_search
+// (BOOLEAN MODE, tiap kata wajib ada, prefix match: "ayam goreng" →
+// "+ayam* +goreng*"). InnoDB mengabaikan kata < 3 huruf (innodb_ft_min_token_size),
+// jadi kata pendek seperti "es" dicari dengan LIKE.
+
+const (
+	maxSearchTerms    = 8
+	minFulltextLength = 3
+)
+
+// MenuSearch hasil parsing ?q=
+type MenuSearch struct {
+	Fulltext []string // kata >= 3 huruf
+	Short    []string // kata pendek → LIKE
+}
+
+// ParseMenuSearch memecah query jadi kata (huruf/angka saja, operator FULLTEXT dibuang)
+func ParseMenuSearch(s string) MenuSearch {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var ms MenuSearch
+	seen := map[string]bool{}
+	for _, w := range words {
+		if seen[w] || len(ms.Fulltext)+len(ms.Short) >= maxSearchTerms {
+			continue
+		}
+		seen[w] = true
+		if len([]rune(w)) >= minFulltextLength {
+			ms.Fulltext = append(ms.Fulltext, w)
+		} else {
+			ms.Short = append(ms.Short, w)
+		}
+	}
+	return ms
+}
+
+func (ms MenuSearch) Empty() bool { return len(ms.Fulltext) == 0 && len(ms.Short) == 0 }
+
+func (ms MenuSearch) booleanQuery() string {
+	parts := make([]string, 0, len(ms.Fulltext))
+	for _, w := range ms.Fulltext {
+		parts = append(parts, "+"+w+"*")
+	}
+	return strings.Join(parts, " ")
+}
+
+// Apply filter WHERE pencarian
+func (ms MenuSearch) Apply(q *gorm.DB) *gorm.DB {
+	if len(ms.Fulltext) > 0 {
+		q = q.Where("MATCH(menus.nama_makanan, menus.deskripsi) AGAINST (? IN BOOLEAN MODE)", ms.booleanQuery())
+	}
+	for _, w := range ms.Short {
+		like := "%" + w + "%"
+		q = q.Where("(menus.nama_makanan LIKE ? OR menus.deskripsi LIKE ?)", like, like)
+	}
+	return q
+}
+
+// OrderByRelevance urut skor FULLTEXT lalu `then` (mis. "menus.id DESC").
+// ORDER BY dibuat dalam 1 ekspresi: Order() berikutnya akan menimpa ekspresi ini.
+func (ms MenuSearch) OrderByRelevance(q *gorm.DB, then string) *gorm.DB {
+	if len(ms.Fulltext) == 0 {
+		return q.Order(then)
+	}
+	return q.Order(clause.OrderBy{Expression: clause.Expr{
+		SQL:  "MATCH(menus.nama_makanan, menus.deskripsi) AGAINST (? IN BOOLEAN MODE) DESC, " + then,
+		Vars: []interface{}{ms.booleanQuery()},
+	}})
+}
+
+// =========================
+// POPULARITAS
+// =========================
+
+// PopularWindow rentang hitung menu terlaris
+const PopularWindow = 30 * 24 * time.Hour
+
+// JoinMenuPopularity LEFT JOIN jumlah terjual (order tidak batal) sejak `since`
+// sebagai kolom pop.sold — dipakai untuk ?sort=popular.
+func JoinMenuPopularity(q *gorm.DB, since time.Time) *gorm.DB {
+	return q.Joins(`LEFT JOIN (
+		SELECT detail_transaksis.menu_id, SUM(detail_transaksis.qty) AS sold
+		FROM detail_transaksis
+		JOIN transaksis ON transaksis.id = detail_transaksis.transaksi_id
+		WHERE transaksis.status NOT IN ? AND transaksis.created_at >= ?
+		GROUP BY detail_transaksis.menu_id
+	) pop ON pop.menu_id = menus.id`, CancelledStatuses, since.UTC())
+}
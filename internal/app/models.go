@@ -19,6 +19,7 @@ const (
 	RoleSuperAdmin UserRole = "super_admin"
 	RoleAdminStan  UserRole = "admin_stan"
 	RoleSiswa      UserRole = "siswa"
+	RoleKasir      UserRole = "kasir" // operator top-up saldo
 )
 
 type MenuJenis string
@@ -28,6 +29,13 @@ const (
 	JenisMinuman MenuJenis = "minuman"
 )
 
+type DiskonTipe string
+
+const (
+	DiskonPersen  DiskonTipe = "persen"
+	DiskonNominal DiskonTipe = "nominal"
+)
+
 type TransaksiStatus string
 
 const (
@@ -35,6 +43,51 @@ const (
 	StatusDimasak        TransaksiStatus = "dimasak"
 	StatusDiantar        TransaksiStatus = "diantar"
 	StatusSampai         TransaksiStatus = "sampai"
+	StatusDibatalkan     TransaksiStatus = "dibatalkan" // dibatalkan siswa
+	StatusDitolak        TransaksiStatus = "ditolak"    // ditolak stan (wajib alasan)
+)
+
+// IsCancelled true untuk order yang batal / ditolak (tidak dihitung pemasukan).
+func (s TransaksiStatus) IsCancelled() bool {
+	return s == StatusDibatalkan || s == StatusDitolak
+}
+
+// CancelledStatuses dipakai di query: status NOT IN ?
+var CancelledStatuses = []TransaksiStatus{StatusDibatalkan, StatusDitolak}
+
+// AllStatuses urutan alur order (untuk validasi filter & badge)
+var AllStatuses = []TransaksiStatus{
+	StatusBelumDikonfirm,
+	StatusDimasak,
+	StatusDiantar,
+	StatusSampai,
+	StatusDibatalkan,
+	StatusDitolak,
+}
+
+type PaymentMethod string
+
+const (
+	PaymentWallet PaymentMethod = "wallet"
+	PaymentCash   PaymentMethod = "cash"
+)
+
+type PaymentStatus string
+
+const (
+	PaymentPaid     PaymentStatus = "paid"     // sudah lunas (saldo terpotong / tunai diterima saat sampai)
+	PaymentPending  PaymentStatus = "pending"  // bayar tunai di stan
+	PaymentRefunded PaymentStatus = "refunded" // saldo dikembalikan (order batal)
+	PaymentVoid     PaymentStatus = "void"     // order tunai batal sebelum dibayar
+)
+
+type WalletTxType string
+
+const (
+	WalletTopup      WalletTxType = "topup"
+	WalletDebit      WalletTxType = "debit"
+	WalletAdjustment WalletTxType = "adjustment" // koreksi manual super admin
+	WalletRefund     WalletTxType = "refund"     // order wallet dibatalkan / ditolak
 )
 
 //
@@ -49,12 +102,15 @@ type User struct {
 	Email              string    `gorm:"size:150;uniqueIndex;not null" json:"email"`
 	PasswordHash       string    `gorm:"size:255;not null" json:"-"`
 	Role               UserRole  `gorm:"size:50;not null" json:"role"`
-	MustChangePassword bool      `gorm:"default:true" json:"must_change_password"`
+	MustChangePassword bool      `gorm:"not null;default:false" json:"must_change_password"` // true → wajib ganti password dulu
 	CreatedBy          *uint     `gorm:"index" json:"-"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
 	Saldo     float64   `gorm:"type:decimal(15,2);default:0"`
 
+	// naik 1 setiap logout-all / ganti password → semua token lama mati
+	TokenVersion int `gorm:"not null;default:0" json:"-"`
+
 	Siswa *Siswa `gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:UserID"`
 
 	Stan  *Stan  `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:UserID"`
@@ -115,6 +171,35 @@ func (s *Stan) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// StanJamBuka 1 slot jam buka mingguan (WIB). Stan tanpa slot sama sekali
+// dianggap selalu buka; jika ada slot, hari tanpa slot = tutup.
+type StanJamBuka struct {
+	ID       uint   `gorm:"primaryKey"`
+	StanID   uint   `gorm:"index;not null"`
+	Hari     int    `gorm:"not null"`        // time.Weekday: 0 = Minggu … 6 = Sabtu
+	JamBuka  string `gorm:"size:5;not null"` // "HH:MM"
+	JamTutup string `gorm:"size:5;not null"` // "HH:MM", setelah JamBuka
+}
+
+// StanTutup tutup sementara (libur, acara sekolah, bahan habis, ...)
+type StanTutup struct {
+	ID       uint      `gorm:"primaryKey"`
+	PublicID string    `gorm:"size:36;uniqueIndex"`
+	StanID   uint      `gorm:"index;not null"`
+	Mulai    time.Time `gorm:"index;not null"`
+	Selesai  time.Time `gorm:"index;not null"`
+	Alasan   string    `gorm:"size:255"`
+
+	CreatedAt time.Time
+}
+
+func (t *StanTutup) BeforeCreate(tx *gorm.DB) error {
+	if t.PublicID == "" {
+		t.PublicID = uuid.NewString()
+	}
+	return nil
+}
+
 //
 // =========================
 // MENU
@@ -124,15 +209,35 @@ func (s *Stan) BeforeCreate(tx *gorm.DB) error {
 type Menu struct {
 	ID          uint      `gorm:"primaryKey"`
 	PublicID    string    `gorm:"size:36;uniqueIndex"`
-	NamaMakanan string    `gorm:"size:100"`
+	NamaMakanan string    `gorm:"size:100;index:idx_menus_search,class:FULLTEXT"`
 	Harga       float64
 	Jenis       MenuJenis
-	Deskripsi   string
+	Deskripsi   string    `gorm:"index:idx_menus_search,class:FULLTEXT"`
 	StanID      uint
+
+	// stok: NULL = tidak terbatas
+	Stok        *int `gorm:"default:null"`
+	IsAvailable bool `gorm:"not null;default:true"`
+
+	// gambar (key di Storage, lihat menu_image.go)
+	ImageKey string `gorm:"size:255"`
+	ThumbKey string `gorm:"size:255"`
+
+	// kategori (milik stan), tag bebas, alergen (daftar tetap, lihat menu_meta.go)
+	KategoriID *uint         `gorm:"index"`
+	Kategori   *MenuKategori `gorm:"constraint:OnUpdate:CASCADE,OnDelete:SET NULL;foreignKey:KategoriID"`
+	Tags       []Tag         `gorm:"many2many:menu_tags"`
+	Alergen    string        `gorm:"size:100"` // "kacang,susu"
+
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 }
 
+// SoldOut true jika menu dimatikan stan atau stok habis.
+func (m Menu) SoldOut() bool {
+	return !m.IsAvailable || (m.Stok != nil && *m.Stok <= 0)
+}
+
 
 func (m *Menu) BeforeCreate(tx *gorm.DB) error {
 	if m.PublicID == "" {
@@ -141,6 +246,37 @@ func (m *Menu) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+//
+// =========================
+// KATEGORI & TAG MENU
+// =========================
+//
+
+// MenuKategori kategori menu yang dibuat stan (mis. "Nasi", "Gorengan")
+type MenuKategori struct {
+	ID       uint   `gorm:"primaryKey"`
+	PublicID string `gorm:"size:36;uniqueIndex"`
+	StanID   uint   `gorm:"not null;uniqueIndex:idx_kategori_stan_nama"`
+	Nama     string `gorm:"size:50;not null;uniqueIndex:idx_kategori_stan_nama"`
+	Urutan   int    `gorm:"not null;default:0"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (k *MenuKategori) BeforeCreate(tx *gorm.DB) error {
+	if k.PublicID == "" {
+		k.PublicID = uuid.NewString()
+	}
+	return nil
+}
+
+// Tag label bebas (lowercase), dipakai bersama semua stan: pedas, vegetarian, ...
+type Tag struct {
+	ID   uint   `gorm:"primaryKey"`
+	Nama string `gorm:"size:30;uniqueIndex;not null"`
+}
+
 //
 // =========================
 // DISKON
@@ -155,6 +291,25 @@ type Diskon struct {
 	Nama        string     `gorm:"size:100;not null"`
 	Persentase  float64    `gorm:"not null"`
 
+	// tipe potongan: persen (Persentase) atau nominal Rupiah per item (Nominal)
+	Tipe    DiskonTipe `gorm:"size:20;not null;default:'persen'"`
+	Nominal float64    `gorm:"not null;default:0"`
+
+	// syarat: minimal belanja (harga normal item yang termasuk diskon)
+	MinBelanja float64 `gorm:"not null;default:0"`
+
+	// cakupan: kosong = semua menu stan
+	Jenis *MenuJenis `gorm:"size:20"`
+	Menus []Menu     `gorm:"many2many:diskon_menus"`
+
+	// happy hour (WIB, "HH:MM"); kosong = sepanjang hari
+	JamMulai   string `gorm:"size:5"`
+	JamSelesai string `gorm:"size:5"`
+
+	// aturan tumpang tindih (lihat discount.go)
+	Prioritas int  `gorm:"not null;default:0"`
+	Stackable bool `gorm:"not null;default:false"`
+
 	TanggalAwal  *time.Time `gorm:"index"`
 	TanggalAkhir *time.Time `gorm:"index"`
 
@@ -181,6 +336,26 @@ type Transaksi struct {
 	StanID    uint            `gorm:"index;not null" json:"-"`
 	SiswaID   uint            `gorm:"index;not null" json:"-"`
 	Status    TransaksiStatus `gorm:"size:50;not null" json:"status"`
+
+	PaymentMethod PaymentMethod `gorm:"size:20;not null;default:'cash'" json:"payment_method"`
+	PaymentStatus PaymentStatus `gorm:"size:20;not null;default:'pending'" json:"payment_status"`
+
+	CancelReason string     `gorm:"type:text" json:"cancel_reason,omitempty"`
+	CancelledAt  *time.Time `json:"cancelled_at,omitempty"`
+
+	// voucher (potongan level order, di luar diskon per item)
+	VoucherID       *uint   `gorm:"index" json:"-"`
+	VoucherKode     string  `gorm:"size:32" json:"voucher_kode,omitempty"`
+	VoucherPotongan float64 `gorm:"not null;default:0" json:"voucher_potongan"`
+
+	// pre-order: ambil di slot istirahat (opsional)
+	PickupSlotID   *uint      `gorm:"index" json:"-"`
+	PickupSlotNama string     `gorm:"size:50" json:"pickup_slot_nama,omitempty"`
+	PickupAt       *time.Time `gorm:"index" json:"pickup_at,omitempty"` // jam mulai slot pada hari pengambilan
+
+	// checkout keranjang multi-stan (nil = order langsung via POST /orders)
+	CheckoutID *uint `gorm:"index" json:"-"`
+
 	CreatedAt time.Time
 	UpdatedAt time.Time
 
@@ -194,6 +369,24 @@ func (t *Transaksi) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// ItemsTotal jumlah subtotal semua detail (setelah diskon per item)
+func (t Transaksi) ItemsTotal() float64 {
+	var total float64
+	for _, d := range t.Details {
+		total += d.Subtotal()
+	}
+	return total
+}
+
+// Total yang dibayar siswa: item - potongan voucher (Details harus di-preload)
+func (t Transaksi) Total() float64 {
+	total := t.ItemsTotal() - t.VoucherPotongan
+	if total < 0 {
+		return 0
+	}
+	return total
+}
+
 //
 // =========================
 // DETAIL TRANSAKSI
@@ -206,26 +399,72 @@ type DetailTransaksi struct {
 	MenuID      uint      `gorm:"index;not null" json:"-"`
 	Qty         int       `gorm:"not null" json:"qty"`
 	HargaBeli   float64   `gorm:"not null" json:"harga_beli"`
+
+	// snapshot saat order dibuat (tidak ikut berubah jika menu / diskon diedit)
+	NamaMenu       string  `gorm:"size:100" json:"nama_menu"`
+	HargaNormal    float64 `gorm:"not null;default:0" json:"harga_normal"`
+	DiskonID       *uint   `gorm:"index" json:"-"`
+	DiskonPublicID string  `gorm:"size:36" json:"diskon_id,omitempty"`
+	DiskonNama     string  `gorm:"size:255" json:"diskon_nama,omitempty"`
+	DiskonPersen   float64 `gorm:"not null;default:0" json:"diskon_persen"`
+
 	CreatedAt   time.Time
 	UpdatedAt   time.Time
 
 	Menu Menu `gorm:"foreignKey:MenuID"`
 }
 
+// MenuName nama menu saat order (fallback ke menu jika baris lama)
+func (d DetailTransaksi) MenuName() string {
+	if d.NamaMenu != "" {
+		return d.NamaMenu
+	}
+	return d.Menu.NamaMakanan
+}
+
+// OriginalPrice harga normal per item saat order
+func (d DetailTransaksi) OriginalPrice() float64 {
+	if d.HargaNormal > 0 {
+		return d.HargaNormal
+	}
+	return d.HargaBeli
+}
+
+// Subtotal qty × harga beli (yang dibayar)
+func (d DetailTransaksi) Subtotal() float64 {
+	return float64(d.Qty) * d.HargaBeli
+}
+
+// GrossSubtotal qty × harga normal
+func (d DetailTransaksi) GrossSubtotal() float64 {
+	return float64(d.Qty) * d.OriginalPrice()
+}
+
+// DiskonAmount total potongan untuk baris ini
+func (d DetailTransaksi) DiskonAmount() float64 {
+	return d.GrossSubtotal() - d.Subtotal()
+}
+
 //
 // =========================
-// WALLET (OPTIONAL / FUTURE)
+// WALLET
 // =========================
 //
 
+// WalletTransaction = ledger saldo.
+// Amount bertanda: + (uang masuk) / - (uang keluar).
 type WalletTransaction struct {
-	ID        uint      `gorm:"primaryKey" json:"-"`
-	PublicID  string    `gorm:"size:36;uniqueIndex;not null" json:"wallet_tx_id"`
-	UserID    uint      `gorm:"index;not null" json:"-"`
-	Amount    float64   `gorm:"not null" json:"amount"`
-	Type      string    `gorm:"size:50;not null" json:"type"` // topup | debit
-	Note      string    `gorm:"type:text" json:"note,omitempty"`
-	CreatedAt time.Time `json:"created_at"`
+	ID          uint         `gorm:"primaryKey" json:"-"`
+	PublicID    string       `gorm:"size:36;uniqueIndex;not null" json:"wallet_tx_id"`
+	UserID      uint         `gorm:"index;not null" json:"-"`
+	TransaksiID *uint        `gorm:"index" json:"-"`
+	CheckoutID  *uint        `gorm:"index" json:"-"` // debit 1x untuk semua order dalam 1 checkout
+	OperatorID  *uint        `gorm:"index" json:"-"` // user yang melakukan topup / adjustment
+	Amount      float64      `gorm:"type:decimal(15,2);not null" json:"amount"`
+	SaldoAfter  float64      `gorm:"type:decimal(15,2);not null;default:0" json:"saldo_after"`
+	Type        WalletTxType `gorm:"size:50;not null" json:"type"` // topup | debit | adjustment | refund
+	Note        string       `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt   time.Time    `json:"created_at"`
 }
 
 func (w *WalletTransaction) BeforeCreate(tx *gorm.DB) error {
@@ -234,3 +473,153 @@ func (w *WalletTransaction) BeforeCreate(tx *gorm.DB) error {
 	}
 	return nil
 }
+
+//
+// =========================
+// IDEMPOTENCY KEY (ORDER)
+// =========================
+//
+
+// IdempotencyKey menyimpan response asli per (user, key),
+// supaya request ulang (double tap / retry) tidak bikin order dobel.
+type IdempotencyKey struct {
+	ID             uint   `gorm:"primaryKey"`
+	UserID         uint   `gorm:"uniqueIndex:idx_idem_user_key;not null"`
+	Key            string `gorm:"size:100;uniqueIndex:idx_idem_user_key;not null"`
+	RequestHash    string `gorm:"size:64;not null"`
+	ResponseStatus int    `gorm:"not null"`
+	ResponseBody   string `gorm:"type:text"`
+	CreatedAt      time.Time
+}
+
+//
+// =========================
+// REFRESH TOKEN (SESSION)
+// =========================
+//
+
+// RefreshToken disimpan dalam bentuk hash (sha256), token mentah
+// hanya dikirim sekali ke client. Setiap refresh → token lama dicabut
+// dan diganti token baru dalam family yang sama (rotation).
+type RefreshToken struct {
+	ID           uint       `gorm:"primaryKey"`
+	UserID       uint       `gorm:"index;not null"`
+	TokenHash    string     `gorm:"size:64;uniqueIndex;not null"`
+	FamilyID     string     `gorm:"size:36;index;not null"`
+	TokenVersion int        `gorm:"not null"`
+	ExpiresAt    time.Time  `gorm:"index;not null"`
+	RevokedAt    *time.Time `gorm:"index"`
+	UserAgent    string     `gorm:"size:255"`
+	IP           string     `gorm:"size:64"`
+	CreatedAt    time.Time
+}
+
+//
+// =========================
+// VOUCHER (PROMO CODE)
+// =========================
+//
+
+// Voucher kode promo (mis. dari OSIS). StanID nil = berlaku di semua stan.
+// Potongan dihitung dari total order setelah diskon otomatis.
+type Voucher struct {
+	ID       uint   `gorm:"primaryKey" json:"-"`
+	PublicID string `gorm:"size:36;uniqueIndex;not null" json:"voucher_id"`
+	Kode     string `gorm:"size:32;uniqueIndex;not null" json:"kode"`
+	Nama     string `gorm:"size:100;not null" json:"nama"`
+	StanID   *uint  `gorm:"index" json:"-"`
+
+	Tipe        DiskonTipe `gorm:"size:20;not null;default:'persen'" json:"tipe"`
+	Persentase  float64    `gorm:"not null;default:0" json:"persentase"`
+	Nominal     float64    `gorm:"not null;default:0" json:"nominal"`
+	MaxPotongan float64    `gorm:"not null;default:0" json:"max_potongan"` // 0 = tanpa batas (tipe persen)
+	MinBelanja  float64    `gorm:"not null;default:0" json:"min_belanja"`
+
+	KuotaTotal   int `gorm:"not null;default:0" json:"kuota_total"`    // 0 = tanpa batas
+	KuotaPerUser int `gorm:"not null;default:0" json:"kuota_per_user"` // 0 = tanpa batas
+	Terpakai     int `gorm:"not null;default:0" json:"terpakai"`
+
+	TanggalAwal  *time.Time `json:"tanggal_awal"`
+	TanggalAkhir *time.Time `json:"tanggal_akhir"`
+	IsActive     bool       `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedBy uint `json:"-"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (v *Voucher) BeforeCreate(tx *gorm.DB) error {
+	if v.PublicID == "" {
+		v.PublicID = uuid.NewString()
+	}
+	return nil
+}
+
+// VoucherRedemption 1 pemakaian voucher (1 order = maks 1 voucher)
+type VoucherRedemption struct {
+	ID          uint    `gorm:"primaryKey"`
+	VoucherID   uint    `gorm:"index;not null"`
+	UserID      uint    `gorm:"index;not null"`
+	TransaksiID uint    `gorm:"uniqueIndex;not null"`
+	Potongan    float64 `gorm:"type:decimal(15,2);not null"`
+	CreatedAt   time.Time
+}
+
+// PickupSlot slot pengambilan pre-order (mis. Istirahat 1), diatur super admin.
+// Kapasitas berlaku per stan per hari.
+type PickupSlot struct {
+	ID         uint   `gorm:"primaryKey" json:"-"`
+	PublicID   string `gorm:"size:36;uniqueIndex;not null" json:"slot_id"`
+	Nama       string `gorm:"size:50;uniqueIndex;not null" json:"nama"`
+	JamMulai   string `gorm:"size:5;not null" json:"jam_mulai"`   // "HH:MM" WIB
+	JamSelesai string `gorm:"size:5;not null" json:"jam_selesai"` // "HH:MM" WIB
+
+	Kapasitas   int  `gorm:"not null;default:0" json:"kapasitas"`    // order per stan per hari, 0 = tanpa batas
+	CutoffMenit int  `gorm:"not null;default:0" json:"cutoff_menit"` // order ditutup N menit sebelum jam_mulai
+	IsActive    bool `gorm:"not null;default:true" json:"is_active"`
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (s *PickupSlot) BeforeCreate(tx *gorm.DB) error {
+	if s.PublicID == "" {
+		s.PublicID = uuid.NewString()
+	}
+	return nil
+}
+
+//
+// =========================
+// KERANJANG & CHECKOUT
+// =========================
+//
+
+// CartItem 1 menu di keranjang siswa (harga selalu dihitung ulang saat dilihat / checkout)
+type CartItem struct {
+	ID        uint `gorm:"primaryKey"`
+	UserID    uint `gorm:"uniqueIndex:idx_cart_user_menu;not null"`
+	MenuID    uint `gorm:"uniqueIndex:idx_cart_user_menu;index;not null"`
+	Qty       int  `gorm:"not null"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+
+	Menu Menu `gorm:"foreignKey:MenuID"`
+}
+
+// Checkout 1 kali bayar keranjang: 1 transaksi per stan, saldo dipotong 1x
+type Checkout struct {
+	ID            uint          `gorm:"primaryKey" json:"-"`
+	PublicID      string        `gorm:"size:36;uniqueIndex;not null" json:"checkout_id"`
+	UserID        uint          `gorm:"index;not null" json:"-"`
+	PaymentMethod PaymentMethod `gorm:"size:20;not null" json:"payment_method"`
+	Total         float64       `gorm:"type:decimal(15,2);not null" json:"total"`
+	CreatedAt     time.Time     `json:"created_at"`
+}
+
+func (c *Checkout) BeforeCreate(tx *gorm.DB) error {
+	if c.PublicID == "" {
+		c.PublicID = uuid.NewString()
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package app
+
+import (
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// =========================
+// PAGINATION HELPER
+// =========================
+
+const (
+	DefaultPageLimit = 20
+	MaxPageLimit     = 100
+)
+
+// Pagination hasil parsing ?page=&limit=
+type Pagination struct {
+	Page  int
+	Limit int
+}
+
+// Offset untuk query SQL
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Limit
+}
+
+// Meta untuk response JSON
+func (p Pagination) Meta(total int64) gin.H {
+	pages := int((total + int64(p.Limit) - 1) / int64(p.Limit))
+	return gin.H{
+		"page":        p.Page,
+		"limit":       p.Limit,
+		"total":       total,
+		"total_pages": pages,
+	}
+}
+
+// ParsePagination membaca ?page= (default 1) & ?limit= (default 20, max 100).
+// Nilai tidak valid di-fallback ke default (tidak error).
+func ParsePagination(c *gin.Context) Pagination {
+	p := Pagination{Page: 1, Limit: DefaultPageLimit}
+
+	if v, err := strconv.Atoi(c.Query("page")); err == nil && v > 0 {
+		p.Page = v
+	}
+	if v, err := strconv.Atoi(c.Query("limit")); err == nil && v > 0 {
+		p.Limit = v
+	}
+	if p.Limit > MaxPageLimit {
+		p.Limit = MaxPageLimit
+	}
+	return p
+}
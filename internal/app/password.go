@@ -0,0 +1,23 @@
+package app
+
+import (
+	"crypto/rand"
+	"math/big"
+)
+
+// tanpa karakter ambigu (0/O, 1/l/I) supaya mudah didikte ke siswa
+const tempPasswordAlphabet = "abcdefghjkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// RandomPassword membuat password sementara (one-time) sepanjang n karakter.
+func RandomPassword(n int) (string, error) {
+	out := make([]byte, n)
+	max := big.NewInt(int64(len(tempPasswordAlphabet)))
+	for i := range out {
+		idx, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		out[i] = tempPasswordAlphabet[idx.Int64()]
+	}
+	return string(out), nil
+}
@@ -0,0 +1,155 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =========================
+// PICKUP SLOT (PRE-ORDER)
+// =========================
+//
+// Siswa pesan pagi, ambil saat istirahat. Slot diatur super admin (jam WIB),
+// pengambilan selalu hari yang sama dengan order.
+//
+// Alur di SiswaCreateOrder (di dalam tx order):
+//   1. ReservePickupSlot: lock baris slot (FOR UPDATE) → cek aktif, batas
+//      waktu order (cutoff), kapasitas stan hari itu.
+//   2. Transaksi menyimpan PickupSlotID, snapshot nama slot & PickupAt.
+//
+// Kapasitas dihitung dari order yang tidak batal, jadi order batal / ditolak
+// otomatis mengembalikan kuota slot.
+
+var (
+	ErrPickupSlotNotFound = errors.New("slot pengambilan tidak ditemukan")
+	ErrPickupSlotInactive = errors.New("slot pengambilan tidak aktif")
+	ErrPickupSlotClosed   = errors.New("order untuk slot ini sudah ditutup")
+	ErrPickupSlotFull     = errors.New("slot pengambilan di stan ini sudah penuh")
+)
+
+// dayStartWIB 00:00 WIB pada hari t
+func dayStartWIB(t time.Time) time.Time {
+	wib := t.In(JakartaLoc())
+	return time.Date(wib.Year(), wib.Month(), wib.Day(), 0, 0, 0, 0, JakartaLoc())
+}
+
+// StartOn waktu mulai slot pada hari `day` (WIB)
+func (s PickupSlot) StartOn(day time.Time) time.Time {
+	m, _ := ParseJam(s.JamMulai)
+	return dayStartWIB(day).Add(time.Duration(m) * time.Minute)
+}
+
+// EndOn waktu selesai slot pada hari `day` (WIB)
+func (s PickupSlot) EndOn(day time.Time) time.Time {
+	m, _ := ParseJam(s.JamSelesai)
+	return dayStartWIB(day).Add(time.Duration(m) * time.Minute)
+}
+
+// CutoffOn batas akhir order untuk slot pada hari `day`
+func (s PickupSlot) CutoffOn(day time.Time) time.Time {
+	return s.StartOn(day).Add(-time.Duration(s.CutoffMenit) * time.Minute)
+}
+
+// ValidatePickupSlot aturan dasar slot (create & update)
+func ValidatePickupSlot(s *PickupSlot) error {
+	s.Nama = strings.TrimSpace(s.Nama)
+	if s.Nama == "" || len([]rune(s.Nama)) > 50 {
+		return fmt.Errorf("nama is required (max 50 characters)")
+	}
+	start, err := ParseJam(s.JamMulai)
+	if err != nil {
+		return fmt.Errorf("invalid jam_mulai, use HH:MM")
+	}
+	end, err := ParseJam(s.JamSelesai)
+	if err != nil {
+		return fmt.Errorf("invalid jam_selesai, use HH:MM")
+	}
+	if end <= start {
+		return fmt.Errorf("jam_selesai must be after jam_mulai")
+	}
+	if s.Kapasitas < 0 {
+		return fmt.Errorf("kapasitas must not be negative")
+	}
+	if s.CutoffMenit < 0 || s.CutoffMenit > start {
+		return fmt.Errorf("cutoff_menit must be between 0 and jam_mulai")
+	}
+	return nil
+}
+
+// ReservePickupSlot memvalidasi slot untuk order hari ini di stan `stanID`.
+// Mengembalikan slot + waktu pengambilan. WAJIB dipanggil di dalam tx order
+// (lock slot menjaga kapasitas tetap benar saat order bersamaan).
+func ReservePickupSlot(tx *gorm.DB, slotPub string, stanID uint, now time.Time) (*PickupSlot, time.Time, error) {
+	var s PickupSlot
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("public_id = ?", strings.TrimSpace(slotPub)).
+		First(&s).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, time.Time{}, ErrPickupSlotNotFound
+	}
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	if !s.IsActive {
+		return nil, time.Time{}, ErrPickupSlotInactive
+	}
+	if !now.Before(s.CutoffOn(now)) {
+		return nil, time.Time{}, ErrPickupSlotClosed
+	}
+
+	if s.Kapasitas > 0 {
+		used, err := PickupSlotUsage(tx, stanID, now)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if used[s.ID] >= int64(s.Kapasitas) {
+			return nil, time.Time{}, ErrPickupSlotFull
+		}
+	}
+
+	return &s, s.StartOn(now).UTC(), nil
+}
+
+// PickupSlotUsage jumlah order (tidak batal) per slot di 1 stan pada hari `day`
+func PickupSlotUsage(db *gorm.DB, stanID uint, day time.Time) (map[uint]int64, error) {
+	start := dayStartWIB(day)
+	var rows []struct {
+		PickupSlotID uint
+		Total        int64
+	}
+	if err := db.Model(&Transaksi{}).
+		Select("pickup_slot_id, COUNT(*) AS total").
+		Where("stan_id = ? AND pickup_slot_id IS NOT NULL", stanID).
+		Where("pickup_at >= ? AND pickup_at < ?", start.UTC(), start.AddDate(0, 0, 1).UTC()).
+		Where("status NOT IN ?", CancelledStatuses).
+		Group("pickup_slot_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	out := map[uint]int64{}
+	for _, r := range rows {
+		out[r.PickupSlotID] = r.Total
+	}
+	return out, nil
+}
+
+// PickupJSON info pengambilan order (nil = ambil langsung, tanpa slot)
+func PickupJSON(t Transaksi) interface{} {
+	if t.PickupAt == nil {
+		return nil
+	}
+	return gin.H{
+		"slot_nama":       t.PickupSlotNama,
+		"pickup_at":       t.PickupAt,
+		"pickup_at_human": FormatTimeWithClock(*t.PickupAt),
+	}
+}
@@ -0,0 +1,111 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+	"gorm.io/gorm"
+)
+
+// Kapasitas slot per stan per hari: penuh → ErrPickupSlotFull, order batal
+// mengembalikan kuota, stan lain tidak terpengaruh, cutoff tetap berlaku.
+func TestReservePickupSlotCapacity(t *testing.T) {
+	db := apptest.OpenDB(t)
+	_, siswa := apptest.SeedSiswa(t, db, 0)
+	stanA := apptest.SeedStan(t, db, "Stan A")
+	stanB := apptest.SeedStan(t, db, "Stan B")
+
+	slot := app.PickupSlot{Nama: "Istirahat 1", JamMulai: "10:00", JamSelesai: "10:30", Kapasitas: 2, CutoffMenit: 30}
+	if err := db.Create(&slot).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Date(2025, 3, 10, 7, 0, 0, 0, app.JakartaLoc())
+
+	// order seperti SiswaCreateOrder: reserve + simpan slot di tx yang sama
+	order := func(stanID uint, at time.Time) (*app.Transaksi, error) {
+		var trx *app.Transaksi
+		err := db.Transaction(func(tx *gorm.DB) error {
+			s, pickupAt, err := app.ReservePickupSlot(tx, slot.PublicID, stanID, at)
+			if err != nil {
+				return err
+			}
+			trx = &app.Transaksi{
+				StanID:         stanID,
+				SiswaID:        siswa.ID,
+				Status:         app.StatusBelumDikonfirm,
+				PaymentMethod:  app.PaymentCash,
+				PaymentStatus:  app.PaymentPending,
+				PickupSlotID:   &s.ID,
+				PickupSlotNama: s.Nama,
+				PickupAt:       &pickupAt,
+			}
+			return tx.Create(trx).Error
+		})
+		return trx, err
+	}
+
+	var placed []*app.Transaksi
+	for i := 0; i < 2; i++ {
+		trx, err := order(stanA.ID, now)
+		if err != nil {
+			t.Fatalf("order %d: %v", i+1, err)
+		}
+		placed = append(placed, trx)
+	}
+	if _, err := order(stanA.ID, now); !errors.Is(err, app.ErrPickupSlotFull) {
+		t.Fatalf("order ke-3 err = %v, want ErrPickupSlotFull", err)
+	}
+
+	used, err := app.PickupSlotUsage(db, stanA.ID, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if used[slot.ID] != 2 {
+		t.Errorf("usage stan A = %d, want 2", used[slot.ID])
+	}
+
+	// kapasitas dihitung per stan
+	if _, err := order(stanB.ID, now); err != nil {
+		t.Fatalf("order stan B: %v", err)
+	}
+
+	// batal → kuota kembali
+	if err := db.Transaction(func(tx *gorm.DB) error {
+		_, err := app.CancelTransaksi(tx, placed[0],
+			[]app.TransaksiStatus{app.StatusBelumDikonfirm}, app.StatusDibatalkan, "")
+		return err
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := order(stanA.ID, now); err != nil {
+		t.Fatalf("order setelah batal: %v", err)
+	}
+	if _, err := order(stanA.ID, now); !errors.Is(err, app.ErrPickupSlotFull) {
+		t.Fatalf("err = %v, want ErrPickupSlotFull", err)
+	}
+
+	// kuota hari berikutnya terpisah
+	if _, err := order(stanA.ID, now.AddDate(0, 0, 1)); err != nil {
+		t.Fatalf("order besok: %v", err)
+	}
+
+	// cutoff 09:30: tepat di batas sudah ditutup
+	cases := []struct {
+		at   time.Time
+		want error
+	}{
+		{time.Date(2025, 3, 11, 9, 29, 0, 0, app.JakartaLoc()), nil},
+		{time.Date(2025, 3, 11, 9, 30, 0, 0, app.JakartaLoc()), app.ErrPickupSlotClosed},
+		{time.Date(2025, 3, 11, 11, 0, 0, 0, app.JakartaLoc()), app.ErrPickupSlotClosed},
+	}
+	for _, tc := range cases {
+		_, err := order(stanB.ID, tc.at)
+		if !errors.Is(err, tc.want) {
+			t.Errorf("order %s: err = %v, want %v", tc.at.Format("15:04"), err, tc.want)
+		}
+	}
+}
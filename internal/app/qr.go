@@ -0,0 +1,87 @@
+package app
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// =========================
+// ORDER QR (PICKUP VERIFICATION)
+// =========================
+//
+// Payload QR di struk: "KNT1.<transaksi_public_id>.<stan_public_id>.<sig>"
+// sig = base64url(HMAC-SHA256(secret, "KNT1.<transaksi>.<stan>"))
+//
+// Secret: env QR_SECRET (fallback JWT_SECRET). Staf stan men-scan QR lalu
+// memanggil POST /api/admin/orders/verify untuk memastikan order asli
+// dan memang milik stannya.
+
+const orderQRPrefix = "KNT1"
+
+var ErrInvalidQR = errors.New("invalid qr payload")
+
+func qrSecret() []byte {
+	sec := os.Getenv("QR_SECRET")
+	if sec == "" {
+		sec = os.Getenv("JWT_SECRET")
+	}
+	if sec == "" {
+		sec = "dev_qr_secret_change_me"
+	}
+	return []byte(sec)
+}
+
+func signQR(msg string) string {
+	mac := hmac.New(sha256.New, qrSecret())
+	mac.Write([]byte(msg))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// OrderQRPayload payload QR bertanda tangan untuk 1 order
+func OrderQRPayload(trxPublicID, stanPublicID string) string {
+	msg := orderQRPrefix + "." + trxPublicID + "." + stanPublicID
+	return msg + "." + signQR(msg)
+}
+
+// ParseOrderQR memvalidasi tanda tangan & mengembalikan public id transaksi + stan
+func ParseOrderQR(payload string) (trxPublicID, stanPublicID string, err error) {
+	parts := strings.Split(strings.TrimSpace(payload), ".")
+	if len(parts) != 4 || parts[0] != orderQRPrefix || parts[1] == "" || parts[2] == "" {
+		return "", "", ErrInvalidQR
+	}
+
+	msg := strings.Join(parts[:3], ".")
+	if !hmac.Equal([]byte(parts[3]), []byte(signQR(msg))) {
+		return "", "", ErrInvalidQR
+	}
+	return parts[1], parts[2], nil
+}
+
+// OrderQRPNG gambar QR (PNG) untuk payload
+func OrderQRPNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
+
+// StanPublicIDs map stan.id → stan.public_id (1 query)
+func StanPublicIDs(ids []uint) map[uint]string {
+	out := map[uint]string{}
+	if len(ids) == 0 {
+		return out
+	}
+
+	var rows []struct {
+		ID       uint
+		PublicID string
+	}
+	DB.Table("stans").Select("id, public_id").Where("id IN ?", ids).Scan(&rows)
+	for _, r := range rows {
+		out[r.ID] = r.PublicID
+	}
+	return out
+}
@@ -0,0 +1,415 @@
+package app
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// =========================
+// RECEIPT (STRUK) THERMAL
+// =========================
+//
+// Struk kasir untuk printer thermal 58mm / 80mm:
+// - PDF dengan lebar kertas thermal (tinggi menyesuaikan isi)
+// - raw ESC/POS (langsung dikirim ke printer USB / serial)
+//
+// Dipakai bersama oleh endpoint siswa & admin stan.
+
+// Lebar kertas thermal yang didukung (mm)
+const (
+	Paper58 = 58
+	Paper80 = 80
+)
+
+var ErrInvalidPaper = errors.New("invalid paper, use 58|80")
+
+// ParsePaperWidth membaca "58" / "80" (kosong → def)
+func ParsePaperWidth(s string, def int) (int, error) {
+	switch strings.TrimSuffix(strings.TrimSpace(s), "mm") {
+	case "":
+		return def, nil
+	case "58":
+		return Paper58, nil
+	case "80":
+		return Paper80, nil
+	}
+	return 0, ErrInvalidPaper
+}
+
+// ShortCode kode pendek order untuk dipanggil di konter (8 karakter awal public id)
+func (t Transaksi) ShortCode() string {
+	code := strings.ReplaceAll(t.PublicID, "-", "")
+	if len(code) > 8 {
+		code = code[:8]
+	}
+	return strings.ToUpper(code)
+}
+
+// ReceiptItem 1 baris item di struk
+type ReceiptItem struct {
+	Nama         string
+	Qty          int
+	HargaNormal  float64
+	HargaBeli    float64
+	DiskonNama   string
+	DiskonPersen float64
+}
+
+// Subtotal qty × harga beli
+func (i ReceiptItem) Subtotal() float64 { return float64(i.Qty) * i.HargaBeli }
+
+// Receipt data struk yang sudah siap dicetak
+type Receipt struct {
+	NamaStan      string
+	ShortCode     string
+	TransaksiID   string
+	NamaSiswa     string
+	Tanggal       time.Time
+	Status        TransaksiStatus
+	PaymentMethod PaymentMethod
+	Pickup        string // "Istirahat 1 09:30" (pre-order), kosong = ambil langsung
+	Items         []ReceiptItem
+
+	Subtotal float64 // harga normal
+	Diskon   float64
+
+	VoucherKode     string
+	VoucherPotongan float64
+
+	Total float64 // yang dibayar
+
+	QRPayload string // QR bertanda tangan untuk verifikasi pengambilan (lihat qr.go)
+}
+
+// NewReceipt menyusun struk dari transaksi (Details.Menu harus di-preload)
+func NewReceipt(trx Transaksi, stan Stan, namaSiswa string) Receipt {
+	r := Receipt{
+		NamaStan:      stan.NamaStan,
+		ShortCode:     trx.ShortCode(),
+		TransaksiID:   trx.PublicID,
+		NamaSiswa:     namaSiswa,
+		Tanggal:       trx.CreatedAt,
+		Status:        trx.Status,
+		PaymentMethod: trx.PaymentMethod,
+	}
+	if trx.PickupAt != nil {
+		r.Pickup = strings.TrimSpace(trx.PickupSlotNama + " " + trx.PickupAt.In(JakartaLoc()).Format("15:04"))
+	}
+	if stan.PublicID != "" {
+		r.QRPayload = OrderQRPayload(trx.PublicID, stan.PublicID)
+	}
+
+	for _, d := range trx.Details {
+		item := ReceiptItem{
+			Nama:         d.MenuName(),
+			Qty:          d.Qty,
+			HargaNormal:  d.OriginalPrice(),
+			HargaBeli:    d.HargaBeli,
+			DiskonNama:   d.DiskonNama,
+			DiskonPersen: d.DiskonPersen,
+		}
+		r.Items = append(r.Items, item)
+		r.Subtotal += d.GrossSubtotal()
+		r.Total += d.Subtotal()
+	}
+	r.Diskon = r.Subtotal - r.Total
+
+	if trx.VoucherPotongan > 0 {
+		r.VoucherKode = trx.VoucherKode
+		r.VoucherPotongan = trx.VoucherPotongan
+		r.Total = trx.Total()
+	}
+
+	return r
+}
+
+// =========================
+// LAYOUT (dipakai PDF & ESC/POS)
+// =========================
+
+// receiptCols jumlah karakter per baris (font standar printer thermal)
+func receiptCols(paper int) int {
+	if paper == Paper58 {
+		return 32
+	}
+	return 48
+}
+
+// lineLR teks kiri + kanan dalam 1 baris selebar cols
+func lineLR(left, right string, cols int) string {
+	space := cols - utf8.RuneCountInString(left) - utf8.RuneCountInString(right)
+	if space < 1 {
+		// kiri dipotong supaya nominal tetap terlihat
+		keep := cols - utf8.RuneCountInString(right) - 1
+		if keep < 0 {
+			keep = 0
+		}
+		left = string([]rune(left)[:keep])
+		space = 1
+	}
+	return left + strings.Repeat(" ", space) + right
+}
+
+// wrapText memecah teks menjadi beberapa baris maksimal cols karakter
+func wrapText(s string, cols int) []string {
+	var lines []string
+	line := ""
+	for _, w := range strings.Fields(s) {
+		for utf8.RuneCountInString(w) > cols {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			r := []rune(w)
+			lines = append(lines, string(r[:cols]))
+			w = string(r[cols:])
+		}
+		switch {
+		case line == "":
+			line = w
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(w) <= cols:
+			line += " " + w
+		default:
+			lines = append(lines, line)
+			line = w
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// receiptLine 1 baris struk
+type receiptLine struct {
+	Text   string
+	Center bool
+	Bold   bool
+	Big    bool // judul (nama stan / kode)
+}
+
+// lines menyusun isi struk untuk lebar kertas tertentu
+func (r Receipt) lines(paper int) []receiptLine {
+	cols := receiptCols(paper)
+	sep := receiptLine{Text: strings.Repeat("-", cols)}
+
+	var out []receiptLine
+	for _, l := range wrapText(r.NamaStan, cols/2) {
+		out = append(out, receiptLine{Text: l, Center: true, Bold: true, Big: true})
+	}
+	out = append(out,
+		receiptLine{Text: FormatDateID(&r.Tanggal, true) + " " + r.Tanggal.In(JakartaLoc()).Format("15:04") + " WIB", Center: true},
+		sep,
+		receiptLine{Text: "KODE ORDER", Center: true},
+		receiptLine{Text: r.ShortCode, Center: true, Bold: true, Big: true},
+		sep,
+	)
+	if r.NamaSiswa != "" {
+		out = append(out, receiptLine{Text: lineLR("Siswa", r.NamaSiswa, cols)})
+	}
+	out = append(out,
+		receiptLine{Text: lineLR("Bayar", string(r.PaymentMethod), cols)},
+		receiptLine{Text: lineLR("Status", string(r.Status), cols)},
+	)
+	if r.Pickup != "" {
+		out = append(out, receiptLine{Text: lineLR("Ambil", r.Pickup, cols), Bold: true})
+	}
+	out = append(out, sep)
+
+	for _, it := range r.Items {
+		for _, l := range wrapText(it.Nama, cols) {
+			out = append(out, receiptLine{Text: l})
+		}
+		qty := fmt.Sprintf("  %d x %s", it.Qty, FormatRupiah(it.HargaNormal))
+		out = append(out, receiptLine{Text: lineLR(qty, FormatRupiah(float64(it.Qty)*it.HargaNormal), cols)})
+
+		if potongan := float64(it.Qty) * (it.HargaNormal - it.HargaBeli); potongan > 0 {
+			label := "  Diskon"
+			if it.DiskonPersen > 0 {
+				label += fmt.Sprintf(" %g%%", it.DiskonPersen)
+			}
+			out = append(out, receiptLine{Text: lineLR(label, "-"+FormatRupiah(potongan), cols)})
+		}
+	}
+
+	out = append(out, sep, receiptLine{Text: lineLR("Subtotal", FormatRupiah(r.Subtotal), cols)})
+	if r.Diskon > 0 {
+		out = append(out, receiptLine{Text: lineLR("Diskon", "-"+FormatRupiah(r.Diskon), cols)})
+	}
+	if r.VoucherPotongan > 0 {
+		out = append(out, receiptLine{Text: lineLR("Voucher "+r.VoucherKode, "-"+FormatRupiah(r.VoucherPotongan), cols)})
+	}
+	out = append(out,
+		receiptLine{Text: lineLR("TOTAL", FormatRupiah(r.Total), cols), Bold: true},
+		sep,
+		receiptLine{Text: "Terima kasih!", Center: true},
+	)
+	return out
+}
+
+// =========================
+// OUTPUT: PDF THERMAL
+// =========================
+
+// ThermalPDF struk PDF dengan lebar kertas 58mm / 80mm
+func (r Receipt) ThermalPDF(paper int) ([]byte, error) {
+	const (
+		margin = 3.0
+		lineH  = 4.0
+	)
+
+	lines := r.lines(paper)
+	width := float64(paper)
+
+	// font monospace (Courier, lebar glyph 0.6 em) supaya kolom rata seperti
+	// di printer: ukuran dihitung agar `cols` karakter pas selebar area cetak
+	cols := float64(receiptCols(paper))
+	fontSize := (width - margin*2) / cols / 0.6 * 72 / 25.4
+
+	height := margin*2 + 6
+	for _, l := range lines {
+		height += lineH
+		if l.Big {
+			height += lineH
+		}
+	}
+
+	// QR di bagian bawah (lebar ±55% kertas)
+	qrSize := width * 0.55
+	var qrPNG []byte
+	if r.QRPayload != "" {
+		png, err := OrderQRPNG(r.QRPayload, 256)
+		if err != nil {
+			return nil, err
+		}
+		qrPNG = png
+		height += qrSize + 2
+	}
+
+	pdf := fpdf.NewCustom(&fpdf.InitType{
+		OrientationStr: "P",
+		UnitStr:        "mm",
+		Size:           fpdf.SizeType{Wd: width, Ht: height},
+	})
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, 0)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	for _, l := range lines {
+		style, size, h := "", fontSize, lineH
+		if l.Bold {
+			style = "B"
+		}
+		if l.Big {
+			// sama seperti ESC/POS GS ! 0x11 (2x lebar & tinggi)
+			size, h = fontSize*2, lineH*2
+		}
+		pdf.SetFont("Courier", style, size)
+
+		align := "L"
+		if l.Center {
+			align = "C"
+		}
+		pdf.CellFormat(0, h, tr(l.Text), "", 1, align, false, 0, "")
+	}
+
+	if qrPNG != nil {
+		opt := fpdf.ImageOptions{ImageType: "PNG"}
+		pdf.RegisterImageOptionsReader("qr", opt, bytes.NewReader(qrPNG))
+		pdf.ImageOptions("qr", (width-qrSize)/2, pdf.GetY()+1, qrSize, qrSize, false, opt, 0, "")
+	}
+
+	var buf bytes.Buffer
+	if err := pdf.Output(&buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// =========================
+// OUTPUT: ESC/POS
+// =========================
+
+var (
+	escInit        = []byte{0x1b, 0x40}             // ESC @
+	escAlignLeft   = []byte{0x1b, 0x61, 0x00}       // ESC a 0
+	escAlignCenter = []byte{0x1b, 0x61, 0x01}       // ESC a 1
+	escBoldOn      = []byte{0x1b, 0x45, 0x01}       // ESC E 1
+	escBoldOff     = []byte{0x1b, 0x45, 0x00}       // ESC E 0
+	escSizeDouble  = []byte{0x1d, 0x21, 0x11}       // GS ! 0x11 (2x lebar & tinggi)
+	escSizeNormal  = []byte{0x1d, 0x21, 0x00}       // GS ! 0
+	escFeedCut     = []byte{0x1d, 0x56, 0x42, 0x03} // GS V B n (feed n baris lalu potong sebagian)
+)
+
+// ESCPOS byte mentah ESC/POS untuk printer thermal.
+// Teks dikonversi ke ASCII supaya aman di code page default printer.
+func (r Receipt) ESCPOS(paper int) []byte {
+	var b bytes.Buffer
+	b.Write(escInit)
+
+	for _, l := range r.lines(paper) {
+		if l.Center {
+			b.Write(escAlignCenter)
+		} else {
+			b.Write(escAlignLeft)
+		}
+		if l.Bold {
+			b.Write(escBoldOn)
+		}
+		if l.Big {
+			b.Write(escSizeDouble)
+		}
+
+		b.WriteString(asciiOnly(l.Text))
+		b.WriteByte('\n')
+
+		if l.Big {
+			b.Write(escSizeNormal)
+		}
+		if l.Bold {
+			b.Write(escBoldOff)
+		}
+	}
+
+	if r.QRPayload != "" {
+		b.Write(escAlignCenter)
+		writeESCPOSQR(&b, r.QRPayload)
+		b.WriteByte('\n')
+	}
+
+	b.Write(escAlignLeft)
+	b.WriteString("\n\n")
+	b.Write(escFeedCut)
+	return b.Bytes()
+}
+
+// writeESCPOSQR mencetak QR native printer (GS ( k, model 2)
+func writeESCPOSQR(b *bytes.Buffer, data string) {
+	n := len(data) + 3
+	b.Write([]byte{0x1d, 0x28, 0x6b, 0x04, 0x00, 0x31, 0x41, 0x32, 0x00}) // model 2
+	b.Write([]byte{0x1d, 0x28, 0x6b, 0x03, 0x00, 0x31, 0x43, 0x06})       // ukuran modul 6
+	b.Write([]byte{0x1d, 0x28, 0x6b, 0x03, 0x00, 0x31, 0x45, 0x31})       // error correction M
+	b.Write([]byte{0x1d, 0x28, 0x6b, byte(n % 256), byte(n / 256), 0x31, 0x50, 0x30})
+	b.WriteString(data)
+	b.Write([]byte{0x1d, 0x28, 0x6b, 0x03, 0x00, 0x31, 0x51, 0x30}) // print
+}
+
+// asciiOnly mengganti karakter non-ASCII dengan "?"
+func asciiOnly(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if r < 0x20 || r > 0x7e {
+			b.WriteByte('?')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
@@ -0,0 +1,41 @@
+package app
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =========================
+// SESSION HELPERS
+// =========================
+
+// HashToken sha256 hex untuk refresh token (yang disimpan hanya hash-nya).
+func HashToken(raw string) string {
+	sum := sha256.Sum256([]byte(raw))
+	return hex.EncodeToString(sum[:])
+}
+
+// RevokeAllSessions mematikan SEMUA token milik user:
+// - token_version naik → access token lama ditolak JWTAuth
+// - semua refresh token aktif dicabut
+func RevokeAllSessions(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&User{}).
+		Where("id = ?", userID).
+		UpdateColumn("token_version", gorm.Expr("token_version + 1")).Error; err != nil {
+		return err
+	}
+	return tx.Model(&RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", time.Now()).Error
+}
+
+// RevokeRefreshFamily mencabut semua refresh token dalam satu family
+// (dipakai saat token lama dipakai ulang → indikasi token dicuri).
+func RevokeRefreshFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
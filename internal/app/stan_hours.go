@@ -0,0 +1,235 @@
+package app
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// =========================
+// JAM BUKA STAN
+// =========================
+//
+// Status buka/tutup stan pada waktu t (WIB):
+//  1. ada StanTutup yang mencakup t        → tutup (alasan dari admin)
+//  2. stan belum mengatur jam buka sama sekali → buka (perilaku lama)
+//  3. ada slot hari ini yang mencakup jam t → buka, selain itu tutup
+//
+// Dipakai direktori stan (GET /api/siswa/stans) dan SiswaCreateOrder.
+
+// NamaHari index = time.Weekday
+var NamaHari = []string{"minggu", "senin", "selasa", "rabu", "kamis", "jumat", "sabtu"}
+
+// ParseHari "senin" / "Senin" → time.Weekday
+func ParseHari(s string) (time.Weekday, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	if s == "jum'at" {
+		s = "jumat"
+	}
+	for i, h := range NamaHari {
+		if h == s {
+			return time.Weekday(i), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid hari %q, use senin|selasa|rabu|kamis|jumat|sabtu|minggu", s)
+}
+
+// StanSchedule jadwal 1 stan (slot mingguan + tutup sementara yang relevan)
+type StanSchedule struct {
+	Hours    []StanJamBuka
+	Closures []StanTutup
+}
+
+// StanStatus hasil cek buka/tutup
+type StanStatus struct {
+	Open    bool
+	Reason  string     // alasan tutup (kosong jika buka)
+	Closure *StanTutup // tutup sementara yang sedang berlaku
+	Today   []StanJamBuka
+}
+
+// StatusAt status stan pada waktu t
+func (s StanSchedule) StatusAt(t time.Time) StanStatus {
+	wib := t.In(JakartaLoc())
+	st := StanStatus{}
+	for _, h := range s.Hours {
+		if time.Weekday(h.Hari) == wib.Weekday() {
+			st.Today = append(st.Today, h)
+		}
+	}
+
+	for i, c := range s.Closures {
+		if !t.Before(c.Mulai) && t.Before(c.Selesai) {
+			st.Closure = &s.Closures[i]
+			st.Reason = "tutup sementara"
+			if c.Alasan != "" {
+				st.Reason = c.Alasan
+			}
+			return st
+		}
+	}
+
+	if len(s.Hours) == 0 {
+		st.Open = true
+		return st
+	}
+
+	now := wib.Hour()*60 + wib.Minute()
+	for _, h := range st.Today {
+		start, err1 := ParseJam(h.JamBuka)
+		end, err2 := ParseJam(h.JamTutup)
+		if err1 == nil && err2 == nil && now >= start && now < end {
+			st.Open = true
+			return st
+		}
+	}
+
+	if len(st.Today) == 0 {
+		st.Reason = "libur hari ini"
+	} else {
+		st.Reason = "di luar jam buka"
+	}
+	return st
+}
+
+// SortJamBuka urut hari (Senin dulu, Minggu terakhir) lalu jam buka
+func SortJamBuka(hours []StanJamBuka) {
+	order := func(h int) int { return (h + 6) % 7 }
+	sort.SliceStable(hours, func(i, j int) bool {
+		if hours[i].Hari != hours[j].Hari {
+			return order(hours[i].Hari) < order(hours[j].Hari)
+		}
+		return hours[i].JamBuka < hours[j].JamBuka
+	})
+}
+
+// ValidateJamBuka slot valid & tidak tumpang tindih di hari yang sama
+func ValidateJamBuka(hours []StanJamBuka) error {
+	type span struct{ start, end int }
+	byDay := map[int][]span{}
+	for _, h := range hours {
+		if h.Hari < 0 || h.Hari > 6 {
+			return fmt.Errorf("invalid hari")
+		}
+		start, err := ParseJam(h.JamBuka)
+		if err != nil {
+			return fmt.Errorf("invalid jam_buka %q, use HH:MM", h.JamBuka)
+		}
+		end, err := ParseJam(h.JamTutup)
+		if err != nil {
+			return fmt.Errorf("invalid jam_tutup %q, use HH:MM", h.JamTutup)
+		}
+		if end <= start {
+			return fmt.Errorf("jam_tutup must be after jam_buka (%s %s-%s)", NamaHari[h.Hari], h.JamBuka, h.JamTutup)
+		}
+		for _, o := range byDay[h.Hari] {
+			if start < o.end && o.start < end {
+				return fmt.Errorf("overlapping jam buka on %s", NamaHari[h.Hari])
+			}
+		}
+		byDay[h.Hari] = append(byDay[h.Hari], span{start, end})
+	}
+	return nil
+}
+
+// LoadStanSchedules jadwal banyak stan sekaligus (2 query).
+// Closure yang dimuat hanya yang belum selesai pada waktu t.
+func LoadStanSchedules(db *gorm.DB, stanIDs []uint, t time.Time) (map[uint]StanSchedule, error) {
+	out := map[uint]StanSchedule{}
+	if len(stanIDs) == 0 {
+		return out, nil
+	}
+
+	var hours []StanJamBuka
+	if err := db.Where("stan_id IN ?", stanIDs).Find(&hours).Error; err != nil {
+		return nil, err
+	}
+	var closures []StanTutup
+	if err := db.
+		Where("stan_id IN ? AND selesai > ?", stanIDs, t.UTC()).
+		Order("mulai ASC").
+		Find(&closures).Error; err != nil {
+		return nil, err
+	}
+
+	for _, h := range hours {
+		s := out[h.StanID]
+		s.Hours = append(s.Hours, h)
+		out[h.StanID] = s
+	}
+	for _, c := range closures {
+		s := out[c.StanID]
+		s.Closures = append(s.Closures, c)
+		out[c.StanID] = s
+	}
+	for id, s := range out {
+		SortJamBuka(s.Hours)
+		out[id] = s
+	}
+	return out, nil
+}
+
+// StanStatusAt status 1 stan (dipakai saat checkout, boleh di dalam tx)
+func StanStatusAt(db *gorm.DB, stanID uint, t time.Time) (StanStatus, error) {
+	m, err := LoadStanSchedules(db, []uint{stanID}, t)
+	if err != nil {
+		return StanStatus{}, err
+	}
+	return m[stanID].StatusAt(t), nil
+}
+
+// =========================
+// JSON
+// =========================
+
+// JamBukaJSON daftar slot jam buka untuk response
+func JamBukaJSON(hours []StanJamBuka) []gin.H {
+	out := make([]gin.H, 0, len(hours))
+	for _, h := range hours {
+		out = append(out, gin.H{
+			"hari":      NamaHari[h.Hari],
+			"jam_buka":  h.JamBuka,
+			"jam_tutup": h.JamTutup,
+		})
+	}
+	return out
+}
+
+// ClosureJSON 1 tutup sementara untuk response
+func ClosureJSON(c StanTutup) gin.H {
+	return gin.H{
+		"closure_id":    c.PublicID,
+		"mulai":         c.Mulai,
+		"selesai":       c.Selesai,
+		"mulai_human":   FormatTimeWithClock(c.Mulai),
+		"selesai_human": FormatTimeWithClock(c.Selesai),
+		"alasan":        c.Alasan,
+	}
+}
+
+// ScheduleJSON status buka + jadwal stan pada waktu t
+func (s StanSchedule) ScheduleJSON(t time.Time) gin.H {
+	st := s.StatusAt(t)
+
+	var closedReason interface{}
+	if !st.Open {
+		closedReason = st.Reason
+	}
+	closures := make([]gin.H, 0, len(s.Closures))
+	for _, c := range s.Closures {
+		closures = append(closures, ClosureJSON(c))
+	}
+
+	return gin.H{
+		"open_now":        st.Open,
+		"closed_reason":   closedReason,
+		"always_open":     len(s.Hours) == 0,
+		"jam_hari_ini":    JamBukaJSON(st.Today),
+		"jam_buka":        JamBukaJSON(s.Hours),
+		"tutup_sementara": closures,
+	}
+}
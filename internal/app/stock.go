@@ -0,0 +1,59 @@
+package app
+
+import (
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// =========================
+// STOCK HELPERS
+// =========================
+
+var (
+	// ErrMenuUnavailable: menu dimatikan stan (is_available = false).
+	ErrMenuUnavailable = errors.New("menu not available")
+	// ErrStockNotEnough: stok menu kurang dari qty pesanan.
+	ErrStockNotEnough = errors.New("stock not enough")
+)
+
+// DecrementStock mengurangi stok secara atomik (UPDATE ... WHERE stok >= qty).
+// Menu dengan stok NULL (unlimited) tidak diubah.
+// WAJIB dipanggil di dalam transaksi (tx).
+func DecrementStock(tx *gorm.DB, m *Menu, qty int) error {
+	if !m.IsAvailable {
+		return ErrMenuUnavailable
+	}
+	if m.Stok == nil {
+		return nil
+	}
+
+	res := tx.Model(&Menu{}).
+		Where("id = ? AND stok IS NOT NULL AND stok >= ?", m.ID, qty).
+		UpdateColumn("stok", gorm.Expr("stok - ?", qty))
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrStockNotEnough
+	}
+	return nil
+}
+
+// RestoreStock mengembalikan stok semua item transaksi (order batal).
+// Menu unlimited (stok NULL) dilewati.
+func RestoreStock(tx *gorm.DB, trxID uint) error {
+	var details []DetailTransaksi
+	if err := tx.Where("transaksi_id = ?", trxID).Find(&details).Error; err != nil {
+		return err
+	}
+
+	for _, d := range details {
+		if err := tx.Model(&Menu{}).
+			Where("id = ? AND stok IS NOT NULL", d.MenuID).
+			UpdateColumn("stok", gorm.Expr("stok + ?", d.Qty)).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// =========================
+// FILE STORAGE
+// =========================
+//
+// Dipakai untuk gambar menu. Driver dipilih lewat env:
+//
+//	STORAGE_DRIVER=local (default)
+//	  UPLOAD_DIR       folder di disk            (default "uploads")
+//	  UPLOAD_BASE_URL  prefix URL publik         (default "/uploads", disajikan gin Static)
+//
+//	STORAGE_DRIVER=s3 (AWS S3 / MinIO / R2 / dsb.)
+//	  S3_ENDPOINT      mis. http://localhost:9000 (kosong = AWS)
+//	  S3_REGION        default "us-east-1"
+//	  S3_BUCKET, S3_ACCESS_KEY, S3_SECRET_KEY
+//	  S3_PATH_STYLE    "false" → virtual-hosted (bucket.host), default path-style
+//	  S3_PUBLIC_URL    prefix URL publik objek (default endpoint/bucket)
+//
+// Untuk development, S3 bisa dites ke MinIO lokal:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+
+var ErrInvalidStorageKey = errors.New("invalid storage key")
+
+// Storage penyimpanan file (key = path relatif, mis. "menus/<id>/abc.jpg")
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Files storage aktif (diisi InitStorage)
+var Files Storage
+
+// InitStorage memilih driver dari env
+func InitStorage() {
+	switch strings.ToLower(os.Getenv("STORAGE_DRIVER")) {
+	case "s3":
+		s, err := NewS3StorageFromEnv()
+		if err != nil {
+			log.Fatalf("[STORAGE] %v", err)
+		}
+		Files = s
+		log.Printf("[STORAGE] s3 bucket=%s endpoint=%s", s.Bucket, s.Endpoint)
+	default:
+		Files = NewLocalStorageFromEnv()
+		log.Printf("[STORAGE] local dir=%s", Files.(*LocalStorage).Dir)
+	}
+}
+
+// cleanKey menolak key kosong / absolut / keluar folder ("..")
+func cleanKey(key string) (string, error) {
+	k := path.Clean(strings.TrimSpace(key))
+	if k == "." || k == "" || strings.HasPrefix(k, "/") || k == ".." || strings.HasPrefix(k, "../") {
+		return "", ErrInvalidStorageKey
+	}
+	return k, nil
+}
+
+// =========================
+// LOCAL DISK
+// =========================
+
+type LocalStorage struct {
+	Dir     string
+	BaseURL string
+}
+
+func NewLocalStorageFromEnv() *LocalStorage {
+	dir := os.Getenv("UPLOAD_DIR")
+	if dir == "" {
+		dir = "uploads"
+	}
+	base := os.Getenv("UPLOAD_BASE_URL")
+	if base == "" {
+		base = "/uploads"
+	}
+	return &LocalStorage{Dir: dir, BaseURL: strings.TrimRight(base, "/")}
+}
+
+func (s *LocalStorage) Put(_ context.Context, key string, data []byte, _ string) error {
+	k, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	dst := filepath.Join(s.Dir, filepath.FromSlash(k))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	// tulis ke file sementara lalu rename → tidak ada file setengah jadi
+	tmp, err := os.CreateTemp(filepath.Dir(dst), ".upload-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), dst)
+}
+
+func (s *LocalStorage) Delete(_ context.Context, key string) error {
+	k, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(filepath.Join(s.Dir, filepath.FromSlash(k)))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (s *LocalStorage) URL(key string) string {
+	return s.BaseURL + "/" + key
+}
+
+// =========================
+// S3-COMPATIBLE (SigV4, tanpa SDK)
+// =========================
+
+type S3Storage struct {
+	Endpoint  string // scheme://host[:port]
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicURL string
+
+	Client *http.Client
+}
+
+func NewS3StorageFromEnv() (*S3Storage, error) {
+	s := &S3Storage{
+		Endpoint:  strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+		Region:    os.Getenv("S3_REGION"),
+		Bucket:    os.Getenv("S3_BUCKET"),
+		AccessKey: os.Getenv("S3_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_SECRET_KEY"),
+		PathStyle: os.Getenv("S3_PATH_STYLE") != "false",
+		PublicURL: strings.TrimRight(os.Getenv("S3_PUBLIC_URL"), "/"),
+		Client:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if s.Region == "" {
+		s.Region = "us-east-1"
+	}
+	if s.Endpoint == "" {
+		s.Endpoint = "https://s3." + s.Region + ".amazonaws.com"
+	}
+	if s.Bucket == "" || s.AccessKey == "" || s.SecretKey == "" {
+		return nil, fmt.Errorf("S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY are required")
+	}
+	if _, err := url.Parse(s.Endpoint); err != nil {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT: %w", err)
+	}
+	return s, nil
+}
+
+// objectURL URL API untuk 1 objek (path-style / virtual-hosted)
+func (s *S3Storage) objectURL(key string) (*url.URL, error) {
+	u, err := url.Parse(s.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+	if s.PathStyle {
+		u.Path = "/" + s.Bucket + "/" + key
+	} else {
+		u.Host = s.Bucket + "." + u.Host
+		u.Path = "/" + key
+	}
+	u.RawPath = s3EscapePath(u.Path)
+	return u, nil
+}
+
+func (s *S3Storage) Put(ctx context.Context, key string, data []byte, contentType string) error {
+	k, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	u, err := s.objectURL(k)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, u.String(), bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.ContentLength = int64(len(data))
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Cache-Control", "public, max-age=31536000, immutable")
+	return s.do(req, data)
+}
+
+func (s *S3Storage) Delete(ctx context.Context, key string) error {
+	k, err := cleanKey(key)
+	if err != nil {
+		return err
+	}
+	u, err := s.objectURL(k)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	return s.do(req, nil)
+}
+
+func (s *S3Storage) URL(key string) string {
+	if s.PublicURL != "" {
+		return s.PublicURL + "/" + key
+	}
+	u, err := s.objectURL(key)
+	if err != nil {
+		return ""
+	}
+	return u.String()
+}
+
+func (s *S3Storage) do(req *http.Request, body []byte) error {
+	s.sign(req, body, time.Now().UTC())
+
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("s3 %s %s: %s: %s", req.Method, req.URL.Path, resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// sign AWS Signature Version 4 (header Authorization)
+func (s *S3Storage) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	// header yang ditandatangani (lowercase, urut)
+	names := []string{"host"}
+	for name := range req.Header {
+		n := strings.ToLower(name)
+		if n == "content-type" || strings.HasPrefix(n, "x-amz-") {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, n := range names {
+		v := req.URL.Host
+		if n != "host" {
+			v = strings.TrimSpace(req.Header.Get(n))
+		}
+		canonHeaders.WriteString(n + ":" + v + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonReq := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	reqHash := sha256.Sum256([]byte(canonReq))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(reqHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	sig := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKey, scope, signedHeaders, sig,
+	))
+}
+
+func hmacSHA256(key []byte, msg string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(msg))
+	return mac.Sum(nil)
+}
+
+// s3EscapePath URI-encode per segmen (aturan SigV4: hanya A-Z a-z 0-9 - _ . ~ yang tidak di-encode)
+func s3EscapePath(p string) string {
+	segs := strings.Split(p, "/")
+	for i, seg := range segs {
+		var b strings.Builder
+		for _, c := range []byte(seg) {
+			if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+				c == '-' || c == '_' || c == '.' || c == '~' {
+				b.WriteByte(c)
+			} else {
+				fmt.Fprintf(&b, "%%%02X", c)
+			}
+		}
+		segs[i] = b.String()
+	}
+	return strings.Join(segs, "/")
+}
@@ -0,0 +1,201 @@
+package app_test
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// fakeS3 stand-in S3 lokal: verifikasi SigV4 (implementasi terpisah dari
+// signer di storage.go) lalu simpan / hapus objek di memori.
+type fakeS3 struct {
+	access  string
+	secret  string
+	region  string
+	mu      sync.Mutex
+	objects map[string][]byte // path (ter-decode) → isi
+	types   map[string]string
+	errs    []error // signature yang ditolak
+}
+
+var authRe = regexp.MustCompile(`^AWS4-HMAC-SHA256 Credential=([^/]+)/(\d{8})/([^/]+)/s3/aws4_request, SignedHeaders=([^,]+), Signature=([0-9a-f]{64})$`)
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.verify(r, body); err != nil {
+		f.errs = append(f.errs, err)
+		http.Error(w, "SignatureDoesNotMatch", http.StatusForbidden)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[r.URL.Path] = body
+		f.types[r.URL.Path] = r.Header.Get("Content-Type")
+	case http.MethodDelete:
+		delete(f.objects, r.URL.Path)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (f *fakeS3) verify(r *http.Request, body []byte) error {
+	m := authRe.FindStringSubmatch(r.Header.Get("Authorization"))
+	if m == nil {
+		return fmt.Errorf("bad authorization header %q", r.Header.Get("Authorization"))
+	}
+	access, date, region, signed, sig := m[1], m[2], m[3], m[4], m[5]
+	if access != f.access || region != f.region {
+		return fmt.Errorf("credential %s/%s", access, region)
+	}
+	amzDate := r.Header.Get("X-Amz-Date")
+	if !strings.HasPrefix(amzDate, date) {
+		return fmt.Errorf("x-amz-date %q tidak cocok dengan scope %s", amzDate, date)
+	}
+
+	sum := sha256.Sum256(body)
+	payloadHash := hex.EncodeToString(sum[:])
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != payloadHash {
+		return fmt.Errorf("payload hash %s, body %s", got, payloadHash)
+	}
+
+	names := strings.Split(signed, ";")
+	if !sort.StringsAreSorted(names) {
+		return fmt.Errorf("signed headers tidak urut: %s", signed)
+	}
+	var canonHeaders strings.Builder
+	for _, n := range names {
+		v := strings.TrimSpace(r.Header.Get(n))
+		if n == "host" {
+			v = r.Host
+		}
+		canonHeaders.WriteString(n + ":" + v + "\n")
+	}
+
+	// path kanonik dari path ter-decode (seperti S3), bukan dari string klien
+	var canonPath strings.Builder
+	for i, seg := range strings.Split(r.URL.Path, "/") {
+		if i > 0 {
+			canonPath.WriteByte('/')
+		}
+		for _, c := range []byte(seg) {
+			if strings.IndexByte("ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz0123456789-_.~", c) >= 0 {
+				canonPath.WriteByte(c)
+			} else {
+				fmt.Fprintf(&canonPath, "%%%02X", c)
+			}
+		}
+	}
+
+	canonReq := strings.Join([]string{r.Method, canonPath.String(), r.URL.RawQuery, canonHeaders.String(), signed, payloadHash}, "\n")
+	reqHash := sha256.Sum256([]byte(canonReq))
+	scope := date + "/" + region + "/s3/aws4_request"
+	toSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(reqHash[:])
+
+	mac := func(key []byte, msg string) []byte {
+		h := hmac.New(sha256.New, key)
+		h.Write([]byte(msg))
+		return h.Sum(nil)
+	}
+	k := mac([]byte("AWS4"+f.secret), date)
+	k = mac(k, region)
+	k = mac(k, "s3")
+	k = mac(k, "aws4_request")
+	if want := hex.EncodeToString(mac(k, toSign)); want != sig {
+		return fmt.Errorf("signature %s, want %s\ncanonical request:\n%s", sig, want, canonReq)
+	}
+	return nil
+}
+
+func TestS3StorageSigV4(t *testing.T) {
+	fake := &fakeS3{
+		access:  "AKIDTEST",
+		secret:  "secret/with+chars",
+		region:  "ap-southeast-3",
+		objects: map[string][]byte{},
+		types:   map[string]string{},
+	}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+
+	t.Setenv("S3_ENDPOINT", srv.URL+"/")
+	t.Setenv("S3_REGION", fake.region)
+	t.Setenv("S3_BUCKET", "kantin")
+	t.Setenv("S3_ACCESS_KEY", fake.access)
+	t.Setenv("S3_SECRET_KEY", fake.secret)
+	t.Setenv("S3_PATH_STYLE", "")
+	t.Setenv("S3_PUBLIC_URL", "")
+	s, err := app.NewS3StorageFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	keys := []string{
+		"menus/abc/photo.jpg",
+		"menus/abc/nasi goreng (spesial)+1.jpg", // spasi & karakter yang wajib di-encode
+		"menus/abc/es-jéruk~v2.webp",
+	}
+	for _, key := range keys {
+		data := []byte("image:" + key)
+		if err := s.Put(ctx, key, data, "image/jpeg"); err != nil {
+			t.Fatalf("put %q: %v", key, err)
+		}
+
+		objPath := "/kantin/" + key
+		if got := string(fake.objects[objPath]); got != string(data) {
+			t.Errorf("stored %q = %q", objPath, got)
+		}
+		if fake.types[objPath] != "image/jpeg" {
+			t.Errorf("content-type %q = %q", objPath, fake.types[objPath])
+		}
+
+		u, err := url.Parse(s.URL(key))
+		if err != nil || u.Path != objPath {
+			t.Errorf("URL(%q) = %q, want path %q", key, s.URL(key), objPath)
+		}
+	}
+
+	if err := s.Delete(ctx, keys[1]); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, ok := fake.objects["/kantin/"+keys[1]]; ok {
+		t.Error("object masih ada setelah delete")
+	}
+
+	if len(fake.errs) > 0 {
+		t.Fatalf("signature ditolak: %v", fake.errs)
+	}
+
+	// secret salah → server menolak, error diteruskan ke pemanggil
+	bad := *s
+	bad.SecretKey = "wrong"
+	if err := bad.Put(ctx, "menus/abc/x.jpg", []byte("x"), "image/jpeg"); err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("put dengan secret salah: err = %v, want 403", err)
+	}
+	if len(fake.errs) != 1 {
+		t.Errorf("rejected = %d, want 1", len(fake.errs))
+	}
+
+	if err := s.Put(ctx, "../etc/passwd", nil, ""); !errors.Is(err, app.ErrInvalidStorageKey) {
+		t.Errorf("put ../etc/passwd: err = %v, want ErrInvalidStorageKey", err)
+	}
+}
@@ -0,0 +1,129 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// =========================
+// TRANSAKSI HELPERS
+// =========================
+
+// ErrStatusChanged: status transaksi sudah berubah (race / request basi).
+var ErrStatusChanged = errors.New("status already changed")
+
+// CancelTransaksi memindahkan transaksi ke status batal (dibatalkan / ditolak)
+// hanya jika status saat ini ada di `from`, lalu:
+// - wallet + paid  → saldo di-refund lewat ledger, payment_status = refunded
+// - cash (pending) → payment_status = void
+// - stok menu dikembalikan
+// - kuota voucher (jika ada) dikembalikan
+// WAJIB dipanggil di dalam transaksi (tx).
+// Return nominal refund (0 jika tunai).
+func CancelTransaksi(tx *gorm.DB, trx *Transaksi, from []TransaksiStatus, to TransaksiStatus, reason string) (float64, error) {
+	now := time.Now()
+
+	payStatus := PaymentVoid
+	if trx.PaymentMethod == PaymentWallet && trx.PaymentStatus == PaymentPaid {
+		payStatus = PaymentRefunded
+	}
+
+	res := tx.Model(&Transaksi{}).
+		Where("id = ? AND status IN ?", trx.ID, from).
+		Updates(map[string]interface{}{
+			"status":         to,
+			"payment_status": payStatus,
+			"cancel_reason":  reason,
+			"cancelled_at":   now,
+			"updated_at":     now,
+		})
+	if res.Error != nil {
+		return 0, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return 0, ErrStatusChanged
+	}
+
+	if err := RestoreStock(tx, trx.ID); err != nil {
+		return 0, err
+	}
+	if err := ReleaseVoucher(tx, trx.ID); err != nil {
+		return 0, err
+	}
+
+	var refund float64
+	if payStatus == PaymentRefunded {
+		r, err := RefundTransaksi(tx, trx.ID, "refund order "+trx.PublicID)
+		if err != nil {
+			return 0, err
+		}
+		refund = r
+	}
+
+	trx.Status = to
+	trx.PaymentStatus = payStatus
+	trx.CancelReason = reason
+	trx.CancelledAt = &now
+
+	return refund, nil
+}
+
+// CompleteTransaksi menandai order "sampai" (sudah diserahkan ke siswa) hanya
+// jika status saat ini ada di `from`. Order tunai dibayar saat diserahkan →
+// payment_status pending berubah jadi paid.
+func CompleteTransaksi(db *gorm.DB, trx *Transaksi, from []TransaksiStatus) error {
+	updates := map[string]interface{}{
+		"status":     StatusSampai,
+		"updated_at": time.Now(),
+	}
+	payStatus := trx.PaymentStatus
+	if trx.PaymentMethod == PaymentCash && trx.PaymentStatus == PaymentPending {
+		payStatus = PaymentPaid
+		updates["payment_status"] = payStatus
+	}
+
+	res := db.Model(&Transaksi{}).
+		Where("id = ? AND status IN ?", trx.ID, from).
+		Updates(updates)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ErrStatusChanged
+	}
+
+	trx.Status = StatusSampai
+	trx.PaymentStatus = payStatus
+	return nil
+}
+
+// ParseStatusFilter membaca ?status= (boleh berulang / dipisah koma).
+// Contoh: ?status=dimasak&status=diantar atau ?status=dimasak,diantar
+func ParseStatusFilter(values []string) ([]TransaksiStatus, error) {
+	out := []TransaksiStatus{}
+	for _, v := range values {
+		for _, part := range strings.Split(v, ",") {
+			part = strings.TrimSpace(part)
+			if part == "" {
+				continue
+			}
+			st := TransaksiStatus(part)
+			valid := false
+			for _, known := range AllStatuses {
+				if st == known {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				return nil, fmt.Errorf("unknown status: %s", part)
+			}
+			out = append(out, st)
+		}
+	}
+	return out, nil
+}
@@ -3,6 +3,8 @@ package app
 import (
 	"fmt"
 	"math"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -11,6 +13,54 @@ func Round2(f float64) float64 {
 	return math.Round(f*100) / 100
 }
 
+// JakartaLoc mengembalikan zona Asia/Jakarta (fallback WIB +07:00
+// jika tzdata tidak tersedia di server).
+func JakartaLoc() *time.Location {
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		return time.FixedZone("WIB", 7*3600)
+	}
+	return loc
+}
+
+// ParseMonthJakarta membaca "YYYY-MM" dan mengembalikan rentang [awal, akhir)
+// bulan tsb menurut waktu Asia/Jakarta, dalam UTC (siap dipakai di query).
+func ParseMonthJakarta(s string) (time.Time, time.Time, error) {
+	m, err := time.ParseInLocation("2006-01", s, JakartaLoc())
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid month, use YYYY-MM")
+	}
+	return m.UTC(), m.AddDate(0, 1, 0).UTC(), nil
+}
+
+// ParseDateRangeJakarta membaca ?from=YYYY-MM-DD & ?to=YYYY-MM-DD (inklusif,
+// tanggal menurut Asia/Jakarta). Kosong = tidak dibatasi.
+// Return [from, to+1hari) dalam UTC.
+func ParseDateRangeJakarta(from, to string) (*time.Time, *time.Time, error) {
+	var start, end *time.Time
+
+	if from != "" {
+		t, err := time.ParseInLocation("2006-01-02", from, JakartaLoc())
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid from date, use YYYY-MM-DD")
+		}
+		u := t.UTC()
+		start = &u
+	}
+	if to != "" {
+		t, err := time.ParseInLocation("2006-01-02", to, JakartaLoc())
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid to date, use YYYY-MM-DD")
+		}
+		u := t.AddDate(0, 0, 1).UTC()
+		end = &u
+	}
+	if start != nil && end != nil && !start.Before(*end) {
+		return nil, nil, fmt.Errorf("from must be before or equal to to")
+	}
+	return start, end, nil
+}
+
 // FormatTimeHuman mengembalikan representasi human-friendly untuk waktu.
 // - jika dalam range +/-24 jam -> relative (mis. "7 menit lalu" / "in 2 hours")
 // - jika di luar -> format pendek "02 Jan 2006 15:04" (waktu lokal Asia/Jakarta)
@@ -148,25 +198,6 @@ func GetActiveDiscount() *Diskon {
 	return &d
 }
 
-// GetActiveDiscountByStan mengambil 1 diskon aktif MILIK STAN
-func GetActiveDiscountByStan(stanID uint) *Diskon {
-	var d Diskon
-	now := time.Now().UTC()
-
-	err := DB.
-		Where(
-			"stan_id = ? AND (tanggal_awal IS NULL OR tanggal_awal <= ?) AND (tanggal_akhir IS NULL OR tanggal_akhir >= ?)",
-			stanID, now, now,
-		).
-		Order("created_at DESC").
-		First(&d).Error
-
-	if err != nil {
-		return nil
-	}
-	return &d
-}
-
 // ApplyDiscount menghitung harga setelah diskon persen
 func ApplyDiscount(price float64, percent float64) float64 {
 	if percent <= 0 {
@@ -213,3 +244,33 @@ func FormatDateID(t *time.Time, short bool) string {
 
 	return fmt.Sprintf("%d %s %d", day, month, year)
 }
+
+// FormatRupiah "Rp 12.500" (pemisah ribuan titik)
+func FormatRupiah(v float64) string {
+	v = Round2(v)
+	neg := v < 0
+	if neg {
+		v = -v
+	}
+
+	whole := int64(v)
+	frac := int64((v-float64(whole))*100 + 0.5)
+
+	digits := strconv.FormatInt(whole, 10)
+	var b strings.Builder
+	for i, ch := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte('.')
+		}
+		b.WriteRune(ch)
+	}
+
+	out := "Rp " + b.String()
+	if frac > 0 {
+		out += fmt.Sprintf(",%02d", frac)
+	}
+	if neg {
+		out = "-" + out
+	}
+	return out
+}
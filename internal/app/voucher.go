@@ -0,0 +1,158 @@
+package app
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =========================
+// VOUCHER (PROMO CODE)
+// =========================
+//
+// Alur di SiswaCreateOrder (di dalam tx order):
+//   1. RedeemVoucher: lock baris voucher (FOR UPDATE) → cek aktif, periode,
+//      stan, minimal belanja, kuota total & kuota per user → catat
+//      VoucherRedemption + naikkan counter Terpakai → isi kolom voucher di transaksi.
+//   2. Total yang dibayar = Transaksi.Total() (item - potongan voucher).
+//
+// Order batal / ditolak → ReleaseVoucher mengembalikan kuota.
+
+var (
+	ErrVoucherNotFound    = errors.New("voucher tidak ditemukan")
+	ErrVoucherInactive    = errors.New("voucher tidak aktif")
+	ErrVoucherNotStarted  = errors.New("voucher belum berlaku")
+	ErrVoucherExpired     = errors.New("voucher sudah kedaluwarsa")
+	ErrVoucherWrongStan   = errors.New("voucher tidak berlaku di stan ini")
+	ErrVoucherMinBelanja  = errors.New("belum memenuhi minimal belanja voucher")
+	ErrVoucherQuotaUsedUp = errors.New("kuota voucher sudah habis")
+	ErrVoucherUserLimit   = errors.New("batas pemakaian voucher untuk akun ini sudah tercapai")
+	ErrVoucherNoPotongan  = errors.New("voucher tidak memberi potongan untuk order ini")
+)
+
+// NormalizeVoucherCode kode voucher case-insensitive, tanpa spasi
+func NormalizeVoucherCode(s string) string {
+	return strings.ToUpper(strings.TrimSpace(s))
+}
+
+// PotonganFor potongan voucher untuk subtotal order
+func (v Voucher) PotonganFor(subtotal float64) float64 {
+	var p float64
+	switch v.Tipe {
+	case DiskonNominal:
+		p = v.Nominal
+	default:
+		p = subtotal * v.Persentase / 100
+		if v.MaxPotongan > 0 && p > v.MaxPotongan {
+			p = v.MaxPotongan
+		}
+	}
+	if p > subtotal {
+		p = subtotal
+	}
+	return Round2(p)
+}
+
+// RedeemVoucher memvalidasi & mencatat pemakaian voucher untuk trx.
+// trx sudah tersimpan (punya ID); subtotal = total item setelah diskon otomatis.
+// WAJIB dipanggil di dalam transaksi (tx).
+func RedeemVoucher(tx *gorm.DB, code string, userID uint, trx *Transaksi, subtotal float64) (*Voucher, error) {
+	var v Voucher
+	err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("kode = ?", NormalizeVoucherCode(code)).
+		First(&v).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrVoucherNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case !v.IsActive:
+		return nil, ErrVoucherInactive
+	case v.TanggalAwal != nil && now.Before(*v.TanggalAwal):
+		return nil, ErrVoucherNotStarted
+	case v.TanggalAkhir != nil && now.After(*v.TanggalAkhir):
+		return nil, ErrVoucherExpired
+	case v.StanID != nil && *v.StanID != trx.StanID:
+		return nil, ErrVoucherWrongStan
+	case subtotal < v.MinBelanja:
+		return nil, ErrVoucherMinBelanja
+	case v.KuotaTotal > 0 && v.Terpakai >= v.KuotaTotal:
+		return nil, ErrVoucherQuotaUsedUp
+	}
+
+	if v.KuotaPerUser > 0 {
+		var used int64
+		if err := tx.Model(&VoucherRedemption{}).
+			Where("voucher_id = ? AND user_id = ?", v.ID, userID).
+			Count(&used).Error; err != nil {
+			return nil, err
+		}
+		if used >= int64(v.KuotaPerUser) {
+			return nil, ErrVoucherUserLimit
+		}
+	}
+
+	potongan := v.PotonganFor(subtotal)
+	if potongan <= 0 {
+		return nil, ErrVoucherNoPotongan
+	}
+
+	if err := tx.Create(&VoucherRedemption{
+		VoucherID:   v.ID,
+		UserID:      userID,
+		TransaksiID: trx.ID,
+		Potongan:    potongan,
+	}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Model(&Voucher{}).
+		Where("id = ?", v.ID).
+		UpdateColumn("terpakai", gorm.Expr("terpakai + 1")).Error; err != nil {
+		return nil, err
+	}
+
+	if err := tx.Model(&Transaksi{}).
+		Where("id = ?", trx.ID).
+		Updates(map[string]interface{}{
+			"voucher_id":       v.ID,
+			"voucher_kode":     v.Kode,
+			"voucher_potongan": potongan,
+		}).Error; err != nil {
+		return nil, err
+	}
+
+	trx.VoucherID = &v.ID
+	trx.VoucherKode = v.Kode
+	trx.VoucherPotongan = potongan
+	v.Terpakai++
+
+	return &v, nil
+}
+
+// ReleaseVoucher mengembalikan kuota voucher dari order yang batal.
+// Aman dipanggil berulang (redemption sudah dihapus → no-op).
+func ReleaseVoucher(tx *gorm.DB, trxID uint) error {
+	var r VoucherRedemption
+	err := tx.Where("transaksi_id = ?", trxID).First(&r).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := tx.Delete(&r).Error; err != nil {
+		return err
+	}
+	return tx.Model(&Voucher{}).
+		Where("id = ? AND terpakai > 0", r.VoucherID).
+		UpdateColumn("terpakai", gorm.Expr("terpakai - 1")).Error
+}
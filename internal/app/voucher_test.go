@@ -0,0 +1,99 @@
+package app_test
+
+import (
+	"errors"
+	"testing"
+
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// newOrder transaksi kosong milik siswa di stan (cukup untuk redeem voucher)
+func newOrder(t *testing.T, db *gorm.DB, stanID, siswaID uint) *app.Transaksi {
+	t.Helper()
+	trx := app.Transaksi{
+		StanID:        stanID,
+		SiswaID:       siswaID,
+		Status:        app.StatusBelumDikonfirm,
+		PaymentMethod: app.PaymentCash,
+		PaymentStatus: app.PaymentPending,
+	}
+	if err := db.Create(&trx).Error; err != nil {
+		t.Fatal(err)
+	}
+	return &trx
+}
+
+func redeem(db *gorm.DB, userID uint, trx *app.Transaksi) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		_, err := app.RedeemVoucher(tx, " hemat ", userID, trx, 20000)
+		return err
+	})
+}
+
+func TestRedeemVoucherQuota(t *testing.T) {
+	db := apptest.OpenDB(t)
+	stan := apptest.SeedStan(t, db, "Kantin Voucher")
+
+	v := app.Voucher{
+		Kode:         "HEMAT",
+		Nama:         "Hemat",
+		Tipe:         app.DiskonNominal,
+		Nominal:      5000,
+		KuotaTotal:   2,
+		KuotaPerUser: 1,
+		IsActive:     true,
+	}
+	if err := db.Create(&v).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	a, sa := apptest.SeedSiswa(t, db, 0)
+	b, sb := apptest.SeedSiswa(t, db, 0)
+	c, sc := apptest.SeedSiswa(t, db, 0)
+
+	orderA := newOrder(t, db, stan.ID, sa.ID)
+	steps := []struct {
+		name   string
+		userID uint
+		trx    *app.Transaksi
+		want   error
+	}{
+		{"user A pertama", a.ID, orderA, nil},
+		{"user A melebihi kuota per user", a.ID, newOrder(t, db, stan.ID, sa.ID), app.ErrVoucherUserLimit},
+		{"user B pakai sisa kuota", b.ID, newOrder(t, db, stan.ID, sb.ID), nil},
+		{"user C kuota total habis", c.ID, newOrder(t, db, stan.ID, sc.ID), app.ErrVoucherQuotaUsedUp},
+	}
+	for _, st := range steps {
+		if err := redeem(db, st.userID, st.trx); !errors.Is(err, st.want) {
+			t.Fatalf("%s: err = %v, want %v", st.name, err, st.want)
+		}
+	}
+
+	var got app.Voucher
+	db.First(&got, v.ID)
+	if got.Terpakai != 2 {
+		t.Fatalf("terpakai = %d, want 2", got.Terpakai)
+	}
+
+	// order A batal → kuota kembali, user C bisa pakai
+	for i := 0; i < 2; i++ { // release kedua kali no-op
+		if err := app.ReleaseVoucher(db, orderA.ID); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.First(&got, v.ID)
+	if got.Terpakai != 1 {
+		t.Fatalf("terpakai setelah release = %d, want 1", got.Terpakai)
+	}
+
+	trxC := newOrder(t, db, stan.ID, sc.ID)
+	if err := redeem(db, c.ID, trxC); err != nil {
+		t.Fatalf("user C setelah release: %v", err)
+	}
+	if trxC.VoucherPotongan != 5000 || trxC.VoucherKode != "HEMAT" {
+		t.Errorf("trx voucher = %q %v, want HEMAT 5000", trxC.VoucherKode, trxC.VoucherPotongan)
+	}
+}
@@ -0,0 +1,169 @@
+package app
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// =========================
+// WALLET HELPERS
+// =========================
+//
+// Aturan:
+// - setiap perubahan saldo WAJIB lewat PostWalletEntry
+// - 1 perubahan = 1 baris ledger (amount bertanda)
+// - SUM(amount) ledger == users.saldo
+
+// ErrInsufficientSaldo dikembalikan jika saldo user kurang dari nominal debit.
+var ErrInsufficientSaldo = errors.New("insufficient saldo")
+
+// LockUserForUpdate mengambil user dengan SELECT ... FOR UPDATE.
+// WAJIB dipanggil di dalam transaksi (tx).
+func LockUserForUpdate(tx *gorm.DB, userID uint) (*User, error) {
+	var u User
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", userID).
+		First(&u).Error; err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// PostWalletEntry mengunci user, mengubah saldo sebesar w.Amount (bertanda)
+// lalu menulis ledger. Saldo tidak boleh jadi negatif.
+// Return saldo setelah perubahan.
+func PostWalletEntry(tx *gorm.DB, w *WalletTransaction) (float64, error) {
+	w.Amount = Round2(w.Amount)
+
+	u, err := LockUserForUpdate(tx, w.UserID)
+	if err != nil {
+		return 0, err
+	}
+
+	after := Round2(u.Saldo + w.Amount)
+	if after < 0 {
+		return u.Saldo, ErrInsufficientSaldo
+	}
+
+	if err := tx.Model(&User{}).
+		Where("id = ?", w.UserID).
+		UpdateColumn("saldo", gorm.Expr("saldo + ?", w.Amount)).Error; err != nil {
+		return u.Saldo, err
+	}
+
+	if w.PublicID == "" {
+		w.PublicID = uuid.NewString()
+	}
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now()
+	}
+	w.SaldoAfter = after
+
+	if err := tx.Create(w).Error; err != nil {
+		return u.Saldo, err
+	}
+
+	return after, nil
+}
+
+// DebitWallet memotong saldo user + menulis ledger "debit" (amount negatif).
+// Row user dikunci dulu supaya dua order bersamaan tidak bisa lolos cek saldo.
+// Return saldo setelah debit.
+func DebitWallet(tx *gorm.DB, userID uint, amount float64, trxID *uint, note string) (float64, error) {
+	return PostWalletEntry(tx, &WalletTransaction{
+		UserID:      userID,
+		TransaksiID: trxID,
+		Amount:      -Round2(amount),
+		Type:        WalletDebit,
+		Note:        note,
+	})
+}
+
+// DebitCheckout memotong saldo 1x untuk seluruh order dalam 1 checkout keranjang.
+// Ledger mencatat checkout_id (bukan transaksi_id); bagian tiap order = Transaksi.Total().
+func DebitCheckout(tx *gorm.DB, userID uint, amount float64, checkoutID uint, note string) (float64, error) {
+	return PostWalletEntry(tx, &WalletTransaction{
+		UserID:     userID,
+		CheckoutID: &checkoutID,
+		Amount:     -Round2(amount),
+		Type:       WalletDebit,
+		Note:       note,
+	})
+}
+
+// CreditWallet menambah saldo user (topup) dengan operator tercatat.
+func CreditWallet(tx *gorm.DB, userID uint, amount float64, operatorID *uint, note string) (float64, error) {
+	return PostWalletEntry(tx, &WalletTransaction{
+		UserID:     userID,
+		OperatorID: operatorID,
+		Amount:     Round2(amount),
+		Type:       WalletTopup,
+		Note:       note,
+	})
+}
+
+// LedgerSaldo menghitung ulang saldo dari ledger (SUM amount).
+func LedgerSaldo(db *gorm.DB, userID uint) (float64, error) {
+	var sum float64
+	err := db.Model(&WalletTransaction{}).
+		Select("COALESCE(SUM(amount), 0)").
+		Where("user_id = ?", userID).
+		Scan(&sum).Error
+	return Round2(sum), err
+}
+
+// RefundTransaksi mengembalikan saldo yang terpotong oleh transaksi trxID.
+// Nominal = -(SUM ledger milik transaksi), jadi aman dipanggil ulang
+// (refund kedua kali nominalnya 0 → tidak menulis apa-apa).
+// Order hasil checkout keranjang tidak punya baris debit sendiri: bagiannya
+// dari debit checkout = Transaksi.Total().
+// Return nominal yang dikembalikan.
+func RefundTransaksi(tx *gorm.DB, trxID uint, note string) (float64, error) {
+	var rows []WalletTransaction
+	if err := tx.
+		Where("transaksi_id = ?", trxID).
+		Find(&rows).Error; err != nil {
+		return 0, err
+	}
+
+	var net float64
+	var userID uint
+	for _, w := range rows {
+		net += w.Amount
+		userID = w.UserID
+	}
+
+	var trx Transaksi
+	if err := tx.Preload("Details").First(&trx, trxID).Error; err != nil {
+		return 0, err
+	}
+	if trx.CheckoutID != nil && trx.PaymentMethod == PaymentWallet {
+		var co Checkout
+		if err := tx.First(&co, *trx.CheckoutID).Error; err != nil {
+			return 0, err
+		}
+		net -= Round2(trx.Total())
+		userID = co.UserID
+	}
+
+	refund := Round2(-net)
+	if userID == 0 || refund <= 0 {
+		return 0, nil
+	}
+
+	if _, err := PostWalletEntry(tx, &WalletTransaction{
+		UserID:      userID,
+		TransaksiID: &trxID,
+		Amount:      refund,
+		Type:        WalletRefund,
+		Note:        note,
+	}); err != nil {
+		return 0, err
+	}
+	return refund, nil
+}
@@ -0,0 +1,78 @@
+package app_test
+
+import (
+	"testing"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+	"github.com/samudsamudra/UKK_kantin/internal/apptest"
+)
+
+// Order hasil checkout tidak punya debit sendiri: refund = Transaksi.Total()
+// (setelah voucher), order lain dalam checkout yang sama tidak tersentuh.
+func TestRefundTransaksiCheckoutShare(t *testing.T) {
+	db := apptest.OpenDB(t)
+	user, siswa := apptest.SeedSiswa(t, db, 50000)
+	stanA := apptest.SeedStan(t, db, "Stan A")
+	stanB := apptest.SeedStan(t, db, "Stan B")
+	nasi := apptest.SeedMenu(t, db, stanA.ID, "Nasi Goreng", 10000)
+	jus := apptest.SeedMenu(t, db, stanB.ID, "Jus Jeruk", 8000)
+
+	checkout := app.Checkout{UserID: user.ID, PaymentMethod: app.PaymentWallet, Total: 23000}
+	if err := db.Create(&checkout).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	orders := []app.Transaksi{
+		{ // 2 × 10.000 - voucher 5.000 = 15.000
+			StanID:          stanA.ID,
+			VoucherPotongan: 5000,
+			Details:         []app.DetailTransaksi{{MenuID: nasi.ID, Qty: 2, HargaBeli: 10000}},
+		},
+		{ // 1 × 8.000
+			StanID:  stanB.ID,
+			Details: []app.DetailTransaksi{{MenuID: jus.ID, Qty: 1, HargaBeli: 8000}},
+		},
+	}
+	for i := range orders {
+		orders[i].SiswaID = siswa.ID
+		orders[i].Status = app.StatusBelumDikonfirm
+		orders[i].PaymentMethod = app.PaymentWallet
+		orders[i].PaymentStatus = app.PaymentPaid
+		orders[i].CheckoutID = &checkout.ID
+		if err := db.Create(&orders[i]).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := app.DebitCheckout(db, user.ID, checkout.Total, checkout.ID, "checkout"); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name   string
+		trxID  uint
+		refund float64
+		saldo  float64
+	}{
+		{"order A (dengan voucher)", orders[0].ID, 15000, 42000},
+		{"order A diulang", orders[0].ID, 0, 42000},
+		{"order B", orders[1].ID, 8000, 50000},
+	}
+	for _, st := range steps {
+		got, err := app.RefundTransaksi(db, st.trxID, "batal")
+		if err != nil {
+			t.Fatalf("%s: %v", st.name, err)
+		}
+		if got != st.refund {
+			t.Errorf("%s: refund = %v, want %v", st.name, got, st.refund)
+		}
+
+		var u app.User
+		db.First(&u, user.ID)
+		if u.Saldo != st.saldo {
+			t.Errorf("%s: saldo = %v, want %v", st.name, u.Saldo, st.saldo)
+		}
+		if ledger, _ := app.LedgerSaldo(db, user.ID); ledger != u.Saldo {
+			t.Errorf("%s: ledger %v != saldo %v", st.name, ledger, u.Saldo)
+		}
+	}
+}
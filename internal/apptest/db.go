@@ -0,0 +1,71 @@
+// Package apptest berisi helper khusus test (DB sqlite in-memory, seed data).
+// Hanya di-import dari file _test.go.
+package apptest
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// OpenDB membuat DB sqlite in-memory baru dengan skema yang sama seperti
+// RunMigrations lalu memasangnya ke app.DB (dikembalikan saat test selesai).
+func OpenDB(t testing.TB) *gorm.DB {
+	t.Helper()
+
+	name := strings.NewReplacer("/", "_", " ", "_").Replace(t.Name())
+	dsn := fmt.Sprintf("file:%s?mode=memory&cache=shared&_pragma=busy_timeout(5000)", name)
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("open sqlite: %v", err)
+	}
+
+	// FULLTEXT index (MySQL) tidak dikenal sqlite → buat tabel menu dulu lalu
+	// pasang index biasa dengan nama yang sama supaya AutoMigrate melewatinya.
+	_ = db.AutoMigrate(&app.Menu{})
+	if err := db.Exec("CREATE INDEX IF NOT EXISTS idx_menus_search ON menus(nama_makanan, deskripsi)").Error; err != nil {
+		t.Fatalf("create menu index: %v", err)
+	}
+
+	if err := db.AutoMigrate(
+		&app.User{},
+		&app.Siswa{},
+		&app.Stan{},
+		&app.StanJamBuka{},
+		&app.StanTutup{},
+		&app.MenuKategori{},
+		&app.Tag{},
+		&app.Menu{},
+		&app.Diskon{},
+		&app.Transaksi{},
+		&app.DetailTransaksi{},
+		&app.WalletTransaction{},
+		&app.IdempotencyKey{},
+		&app.RefreshToken{},
+		&app.Voucher{},
+		&app.VoucherRedemption{},
+		&app.PickupSlot{},
+		&app.CartItem{},
+		&app.Checkout{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	prev := app.DB
+	app.DB = db
+	t.Cleanup(func() {
+		app.DB = prev
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
@@ -0,0 +1,67 @@
+package apptest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/samudsamudra/UKK_kantin/internal/app"
+)
+
+// SeedSiswa membuat user role siswa + profil siswa dengan saldo awal
+// (saldo awal ikut dicatat di ledger supaya SUM(ledger) == saldo).
+func SeedSiswa(t testing.TB, db *gorm.DB, saldo float64) (*app.User, *app.Siswa) {
+	t.Helper()
+
+	u := app.User{
+		Email:        fmt.Sprintf("%s@siswa.test", uuid.NewString()[:8]),
+		PasswordHash: "-",
+		Role:         app.RoleSiswa,
+	}
+	if err := db.Create(&u).Error; err != nil {
+		t.Fatalf("seed user: %v", err)
+	}
+	s := app.Siswa{PublicID: uuid.NewString(), Nama: "Siswa " + u.Email, UserID: u.ID}
+	if err := db.Create(&s).Error; err != nil {
+		t.Fatalf("seed siswa: %v", err)
+	}
+
+	if saldo > 0 {
+		if _, err := app.CreditWallet(db, u.ID, saldo, nil, "seed"); err != nil {
+			t.Fatalf("seed saldo: %v", err)
+		}
+		u.Saldo = saldo
+	}
+	return &u, &s
+}
+
+// SeedStan membuat stan tanpa jam buka (dianggap selalu buka)
+func SeedStan(t testing.TB, db *gorm.DB, nama string) *app.Stan {
+	t.Helper()
+
+	s := app.Stan{PublicID: uuid.NewString(), NamaStan: nama}
+	if err := db.Create(&s).Error; err != nil {
+		t.Fatalf("seed stan: %v", err)
+	}
+	return &s
+}
+
+// SeedMenu membuat menu makanan tersedia tanpa batas stok
+func SeedMenu(t testing.TB, db *gorm.DB, stanID uint, nama string, harga float64) *app.Menu {
+	t.Helper()
+
+	m := app.Menu{
+		PublicID:    uuid.NewString(),
+		NamaMakanan: nama,
+		Harga:       harga,
+		Jenis:       app.JenisMakanan,
+		StanID:      stanID,
+		IsAvailable: true,
+	}
+	if err := db.Create(&m).Error; err != nil {
+		t.Fatalf("seed menu: %v", err)
+	}
+	return &m
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/samudsamudra/UKK_kantin/internal/api"
+	"github.com/samudsamudra/UKK_kantin/internal/app"
 )
 
 //
@@ -79,6 +80,11 @@ func requireJSON(maxBytes int64) gin.HandlerFunc {
 //
 
 func Register(r *gin.Engine) {
+	// file upload (gambar menu) untuk storage lokal
+	if ls, ok := app.Files.(*app.LocalStorage); ok {
+		r.Static(ls.BaseURL, ls.Dir)
+	}
+
 	// root API group
 	apiGroup := r.Group("/api")
 
@@ -98,6 +104,24 @@ func Register(r *gin.Engine) {
 		simpleRateLimiter(10, time.Minute),
 		api.Login,
 	)
+	// akun must_change_password hanya boleh akses endpoint ini
+	apiGroup.POST(
+		"/auth/change-password",
+		simpleRateLimiter(10, time.Minute),
+		api.JWTAuthAllowPasswordChange(),
+		api.ChangePassword,
+	)
+	apiGroup.POST(
+		"/auth/refresh",
+		simpleRateLimiter(30, time.Minute),
+		api.Refresh,
+	)
+	apiGroup.POST("/auth/logout", api.Logout)
+	apiGroup.POST(
+		"/auth/logout-all",
+		api.JWTAuthAllowPasswordChange(),
+		api.LogoutAll,
+	)
 
 	// =========================
 	// SISWA
@@ -107,6 +131,18 @@ func Register(r *gin.Engine) {
 	// public endpoints (no auth)
 	siswa.GET("/menus", api.SiswaListMenus)
 	siswa.GET("/menus/:id", api.SiswaGetMenu)
+	siswa.GET("/stans", api.SiswaListStans)
+	siswa.GET("/stans/:id", api.SiswaGetStan)
+	siswa.GET("/pickup-slots", api.SiswaListPickupSlots)
+
+	// realtime (SSE): token boleh via ?access_token= (EventSource)
+	siswa.GET(
+		"/orders/stream",
+		api.SSETokenFromQuery(),
+		api.JWTAuth(),
+		api.RequireRole("siswa"),
+		api.SiswaOrdersStream,
+	)
 
 	// protected siswa endpoints
 	siswaAuth := siswa.Group("")
@@ -114,15 +150,26 @@ func Register(r *gin.Engine) {
 	{
 		// wallet
 		siswaAuth.GET("/wallet", api.SiswaGetWallet)
+		siswaAuth.GET("/wallet/transactions", api.SiswaWalletTransactions)
 
 		// order
 		siswaAuth.POST("/order", api.SiswaCreateOrder)
 
+		// keranjang (multi-stan) → checkout = 1 transaksi per stan
+		siswaAuth.GET("/cart", api.SiswaGetCart)
+		siswaAuth.DELETE("/cart", api.SiswaClearCart)
+		siswaAuth.POST("/cart/items", api.SiswaAddCartItem)
+		siswaAuth.PATCH("/cart/items/:menu_id", api.SiswaUpdateCartItem)
+		siswaAuth.DELETE("/cart/items/:menu_id", api.SiswaRemoveCartItem)
+		siswaAuth.POST("/cart/checkout", api.SiswaCheckoutCart)
+
 		// GET /api/siswa/orders?month=YYYY-MM
 		siswaAuth.GET("/orders", api.SiswaOrdersByMonth)
+		siswaAuth.POST("/orders/:id/cancel", api.SiswaCancelOrder)
 
 		// receipt
-		siswaAuth.GET("/orders/:id/receipt/pdf", api.SiswaGetOrderReceiptPDF)
+		siswaAuth.GET("/orders/:id/receipt/pdf", api.SiswaGetOrderReceiptPDF)       // ?paper=58|80 → thermal
+		siswaAuth.GET("/orders/:id/receipt/escpos", api.SiswaGetOrderReceiptESCPOS) // raw ESC/POS
 
 		// (UKK opsional lanjutan)
 		// siswaAuth.GET("/orders/:id/receipt", api.SiswaGetReceipt)
@@ -143,6 +190,15 @@ func Register(r *gin.Engine) {
 		api.RegisterStan,
 	)
 
+	// realtime (SSE): token boleh via ?access_token= (EventSource)
+	admin.GET(
+		"/orders/stream",
+		api.SSETokenFromQuery(),
+		api.JWTAuth(),
+		api.RequireRole("admin_stan"),
+		api.AdminOrdersStream,
+	)
+
 	adminAuth := admin.Group("")
 	adminAuth.Use(api.JWTAuth(), api.RequireRole("admin_stan"))
 	{
@@ -153,6 +209,20 @@ func Register(r *gin.Engine) {
 		adminAuth.DELETE("/menus/:id", api.AdminDeleteMenu)
 		adminAuth.GET("/menus", api.AdminListMenus)
 		adminAuth.GET("/menus/:id", api.AdminGetMenu)
+		adminAuth.POST("/menus/:id/image", api.AdminUploadMenuImage)
+		adminAuth.DELETE("/menus/:id/image", api.AdminDeleteMenuImage)
+
+		// ----- kategori menu -----
+		adminAuth.POST("/categories", api.AdminCreateKategori)
+		adminAuth.GET("/categories", api.AdminListKategori)
+		adminAuth.PUT("/categories/:id", api.AdminUpdateKategori)
+		adminAuth.DELETE("/categories/:id", api.AdminDeleteKategori)
+
+		// ----- jam buka & tutup sementara -----
+		adminAuth.GET("/stan/hours", api.AdminGetStanHours)
+		adminAuth.PUT("/stan/hours", api.AdminSetStanHours)
+		adminAuth.POST("/stan/closures", api.AdminCreateStanClosure)
+		adminAuth.DELETE("/stan/closures/:id", api.AdminDeleteStanClosure)
 
 		// ----- discount -----
 		adminAuth.PATCH("/discounts", api.AdminCreateDiscount)
@@ -163,13 +233,32 @@ func Register(r *gin.Engine) {
 
 		// ----- orders -----
 		adminAuth.GET("/orders", api.AdminOrders)
+		adminAuth.POST("/orders/verify", api.AdminVerifyOrderQR) // scan QR struk
 		adminAuth.PATCH("/orders/:id/status", api.AdminUpdateOrderStatus)
+		adminAuth.GET("/orders/:id/receipt/pdf", api.AdminOrderReceiptPDF)
+		adminAuth.GET("/orders/:id/receipt/escpos", api.AdminOrderReceiptESCPOS)
 
 		// ----- reports -----
-		// adminAuth.GET("/reports/monthly", api.AdminMonthlyReport)
+		adminAuth.GET("/reports/monthly", api.AdminMonthlyReport)
 		adminAuth.GET("/reports/rekap", api.AdminRekapTransaksi)
 	}
 
+	// =========================
+	// WALLET (SUPER ADMIN / KASIR)
+	// =========================
+	walletOps := admin.Group("/wallet")
+	walletOps.Use(api.JWTAuth(), api.RequireAnyRole("super_admin", "kasir"))
+	{
+		walletOps.POST("/topup", api.SiswaTopupByAdmin)
+		walletOps.GET("/:user_id", api.AdminWalletLedger)
+	}
+	admin.POST(
+		"/wallet/adjust",
+		api.JWTAuth(),
+		api.RequireSuperAdmin(),
+		api.AdminWalletAdjust,
+	)
+
 	// =========================
 	// SYSTEM (SUPER ADMIN ONLY)
 	// =========================
@@ -197,5 +286,42 @@ func Register(r *gin.Engine) {
 		api.RequireSuperAdmin(),
 		api.AdminGetAllStan,
 	)
+	admin.GET(
+		"/system/discounts",
+		api.JWTAuth(),
+		api.RequireSuperAdmin(),
+		api.AdminListAllDiscounts,
+	)
+	admin.POST(
+		"/system/users/:id/reset-password",
+		api.JWTAuth(),
+		api.RequireSuperAdmin(),
+		api.AdminResetPassword,
+	)
+	admin.POST(
+		"/system/kasir",
+		api.JWTAuth(),
+		api.RequireSuperAdmin(),
+		api.RegisterKasir,
+	)
+
+	// =========================
+	// VOUCHERS (SUPER ADMIN ONLY)
+	// =========================
+	vouchers := admin.Group("/vouchers", api.JWTAuth(), api.RequireSuperAdmin())
+	vouchers.POST("", api.AdminCreateVoucher)
+	vouchers.GET("", api.AdminListVouchers)
+	vouchers.GET("/:id", api.AdminGetVoucher)
+	vouchers.PUT("/:id", api.AdminUpdateVoucher)
+	vouchers.DELETE("/:id", api.AdminDeleteVoucher)
+
+	// =========================
+	// PICKUP SLOT PRE-ORDER (SUPER ADMIN ONLY)
+	// =========================
+	pickupSlots := admin.Group("/pickup-slots", api.JWTAuth(), api.RequireSuperAdmin())
+	pickupSlots.POST("", api.AdminCreatePickupSlot)
+	pickupSlots.GET("", api.AdminListPickupSlots)
+	pickupSlots.PUT("/:id", api.AdminUpdatePickupSlot)
+	pickupSlots.DELETE("/:id", api.AdminDeletePickupSlot)
 
 }
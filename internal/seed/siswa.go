@@ -33,13 +33,17 @@ func SeedSiswas() {
 			Email:        s.Email,
 			PasswordHash: string(hash),
 			Role:         app.RoleSiswa,
-			Saldo:        50000, // 🔥 biar bisa langsung order
 		}
 		if err := db.Create(&user).Error; err != nil {
 			log.Println("[SEED] failed create siswa user")
 			continue
 		}
 
+		// 🔥 saldo awal lewat ledger, biar bisa langsung order
+		if _, err := app.CreditWallet(db, user.ID, 50000, nil, "saldo awal (seed)"); err != nil {
+			log.Println("[SEED] failed seed saldo:", err)
+		}
+
 		siswa := app.Siswa{
 			Nama:   s.Nama,
 			UserID: user.ID,